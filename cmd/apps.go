@@ -1,19 +1,33 @@
 package cmd
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/deis/controller-sdk-go"
 	"github.com/deis/controller-sdk-go/api"
 	"github.com/deis/controller-sdk-go/apps"
+	"github.com/deis/controller-sdk-go/appsettings"
 	"github.com/deis/controller-sdk-go/config"
 	"github.com/deis/controller-sdk-go/domains"
+	dtime "github.com/deis/controller-sdk-go/pkg/time"
+	"github.com/deis/controller-sdk-go/releases"
+	"github.com/deis/controller-sdk-go/tls"
 	"github.com/deis/workflow-cli/pkg/git"
 	"github.com/deis/workflow-cli/pkg/logging"
 	"github.com/deis/workflow-cli/pkg/webbrowser"
 	"github.com/deis/workflow-cli/settings"
+	"github.com/olekukonko/tablewriter"
 )
 
 // AppCreate creates an app.
@@ -67,8 +81,30 @@ func (d *DeisCmd) AppCreate(id, buildpack, remote string, noRemote bool) error {
 	return nil
 }
 
-// AppsList lists apps on the Deis controller.
-func (d *DeisCmd) AppsList(results int) error {
+// AppsList lists apps on the Deis controller. Apps can be filtered by owner, by a glob pattern
+// on their name, by creation date and by tags, and sorted by name, owner, created or
+// updated. The limit applies after filtering.
+func (d *DeisCmd) AppsList(results int, owner, name string, createdBefore time.Time, tags []string,
+	sortBy string, reverse bool) error {
+	if name != "" {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("%s is not a valid name pattern", name)
+		}
+	}
+
+	if sortBy != "" && appSortKeys[sortBy] == nil {
+		return fmt.Errorf("cannot sort by %s, must be one of name, owner, created or updated", sortBy)
+	}
+
+	if reverse && sortBy == "" {
+		return errors.New("--reverse needs --sort, such as --sort=name")
+	}
+
+	tagMap, err := parseTags(tags)
+	if err != nil {
+		return err
+	}
+
 	s, err := settings.Load(d.ConfigFile)
 
 	if err != nil {
@@ -79,21 +115,259 @@ func (d *DeisCmd) AppsList(results int) error {
 		results = s.Limit
 	}
 
-	apps, count, err := apps.List(s.Client, results)
+	if owner == "" && name == "" && createdBefore.IsZero() && len(tagMap) == 0 && sortBy == "" {
+		apps, count, err := apps.List(s.Client, results)
+		if d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+
+		d.Printf("=== Apps%s", limitCount(len(apps), count))
+
+		for _, app := range apps {
+			d.Println(app.ID)
+		}
+		return nil
+	}
+
+	// filtering and sorting need every app, not just the first page.
+	appList, count, err := apps.List(s.Client, s.Limit)
 	if d.checkAPICompatibility(s.Client, err) != nil {
 		return err
 	}
+	if count > len(appList) {
+		if appList, _, err = apps.List(s.Client, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+	}
+
+	var matched api.Apps
+	for _, app := range appList {
+		if owner != "" && app.Owner != owner {
+			continue
+		}
+		if match, _ := path.Match(name, app.ID); name != "" && !match {
+			continue
+		}
+		// an app whose creation time can't be parsed can't be shown to be old enough.
+		if created := parseAppTime(app.Created); !createdBefore.IsZero() && (created.IsZero() ||
+			!created.Before(createdBefore)) {
+			continue
+		}
+
+		matched = append(matched, app)
+	}
+
+	// tags are fetched per app, so only for the apps which passed the other filters.
+	if len(tagMap) > 0 {
+		if matched, err = d.filterAppsByTags(s.Client, matched, tagMap); err != nil {
+			return err
+		}
+	}
+
+	if sortBy != "" {
+		sorted := appsByKey{apps: matched, key: appSortKeys[sortBy]}
+		if reverse {
+			sort.Stable(sort.Reverse(sorted))
+		} else {
+			sort.Stable(sorted)
+		}
+	}
+
+	shown := matched
+	if len(shown) > results {
+		shown = shown[:results]
+	}
 
-	d.Printf("=== Apps%s", limitCount(len(apps), count))
+	d.Printf("=== Apps%s", limitCount(len(shown), len(matched)))
 
-	for _, app := range apps {
+	for _, app := range shown {
 		d.Println(app.ID)
 	}
 	return nil
 }
 
+// appSortKeys are the keys apps:list can sort by. Timestamps are keyed in UTC, so they sort
+// in time order.
+var appSortKeys = map[string]func(api.App) string{
+	"name":  func(app api.App) string { return app.ID },
+	"owner": func(app api.App) string { return app.Owner },
+	"created": func(app api.App) string {
+		return parseAppTime(app.Created).UTC().Format(time.RFC3339)
+	},
+	"updated": func(app api.App) string {
+		return parseAppTime(app.Updated).UTC().Format(time.RFC3339)
+	},
+}
+
+// appsByKey sorts apps by a key.
+type appsByKey struct {
+	apps api.Apps
+	key  func(api.App) string
+}
+
+func (a appsByKey) Len() int           { return len(a.apps) }
+func (a appsByKey) Swap(i, j int)      { a.apps[i], a.apps[j] = a.apps[j], a.apps[i] }
+func (a appsByKey) Less(i, j int) bool { return a.key(a.apps[i]) < a.key(a.apps[j]) }
+
+// parseAppTime parses an app's created or updated timestamp. Unparseable timestamps are
+// treated as the zero time.
+func parseAppTime(timestamp string) time.Time {
+	for _, layout := range []string{dtime.DeisDatetimeFormat, time.RFC3339} {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// AppsStatus prints a summary of every app the user can see: its processes, latest release,
+// routing, maintenance and HTTPS settings and number of domains. Apps are fetched by a pool
+// of workers, so that at most workers apps are queried at once. Unhealthy apps are listed
+// first.
+func (d *DeisCmd) AppsStatus(workers int) error {
+	s, err := settings.Load(d.ConfigFile)
+
+	if err != nil {
+		return err
+	}
+
+	appList, count, err := apps.List(s.Client, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	if count > len(appList) {
+		if appList, _, err = apps.List(s.Client, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+	}
+
+	statuses := make([]appStatus, len(appList))
+	forEachApp(len(appList), workers, func(i int) {
+		statuses[i] = fetchAppStatus(s.Client, appList[i].ID, s.Limit)
+	})
+
+	table := tablewriter.NewWriter(d.WOut)
+	table.SetHeader([]string{"App", "Processes", "Release", "Routable", "Maintenance", "HTTPS", "Domains", "Status"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderLine(true)
+
+	// unhealthy apps are listed first, so they stand out among many healthy ones.
+	var unhealthy, healthy []int
+	for i, status := range statuses {
+		if len(status.problems) > 0 {
+			unhealthy = append(unhealthy, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+
+	for _, i := range append(unhealthy, healthy...) {
+		status := statuses[i]
+		health := "OK"
+		if len(status.problems) > 0 {
+			health = "UNHEALTHY: " + strings.Join(status.problems, ", ")
+		}
+
+		if status.err != nil {
+			table.Append([]string{appList[i].ID, "", "", "", "", "", "", health})
+			continue
+		}
+
+		table.Append([]string{appList[i].ID, status.processes, status.release, status.routable,
+			status.maintenance, status.https, strconv.Itoa(status.domains), health})
+	}
+	table.Render()
+
+	d.Printf("\n%d app(s), %d unhealthy\n", len(appList), len(unhealthy))
+	return nil
+}
+
+// appStatus summarizes an app for apps:status.
+type appStatus struct {
+	processes   string
+	release     string
+	routable    string
+	maintenance string
+	https       string
+	domains     int
+	problems    []string
+	err         error
+}
+
+// fetchAppStatus queries the controller for the state of an app. A process which isn't up
+// makes the app unhealthy, as does failing to query it.
+func fetchAppStatus(c *deis.Client, appID string, limit int) appStatus {
+	status := appStatus{}
+	fail := func(err error) appStatus {
+		status.err = err
+		// errors from the controller can span lines, which a table cell can't.
+		status.problems = append(status.problems, strings.TrimSpace(strings.SplitN(err.Error(), "\n", 2)[0]))
+		return status
+	}
+
+	processes, err := listProcesses(c, appID, limit)
+	if err != nil {
+		return fail(err)
+	}
+
+	up := 0
+	states := make(map[string]int)
+	for _, process := range processes {
+		if process.State == "up" {
+			up++
+		} else {
+			states[process.State]++
+		}
+	}
+	status.processes = fmt.Sprintf("%d/%d up", up, len(processes))
+	for state, n := range states {
+		status.problems = append(status.problems, fmt.Sprintf("%d %s", n, state))
+	}
+	sort.Strings(status.problems)
+
+	releaseList, _, err := releases.List(c, appID, 1)
+	if err != nil {
+		return fail(err)
+	}
+	status.release = "none"
+	if len(releaseList) > 0 {
+		status.release = fmt.Sprintf("v%d", releaseList[0].Version)
+	}
+
+	appSettings, err := appsettings.List(c, appID)
+	if err != nil {
+		return fail(err)
+	}
+	status.routable = "yes"
+	if appSettings.Routable != nil && !*appSettings.Routable {
+		status.routable = "no"
+	}
+	status.maintenance = "off"
+	if appSettings.Maintenance != nil && *appSettings.Maintenance {
+		status.maintenance = "on"
+	}
+
+	appTLS, err := tls.Info(c, appID)
+	if err != nil {
+		return fail(err)
+	}
+	status.https = "optional"
+	if appTLS.HTTPSEnforced != nil && *appTLS.HTTPSEnforced {
+		status.https = "enforced"
+	}
+
+	if _, status.domains, err = domains.List(c, appID, 1); err != nil {
+		return fail(err)
+	}
+
+	return status
+}
+
 // AppInfo prints info about app.
-func (d *DeisCmd) AppInfo(appID string) error {
+func (d *DeisCmd) AppInfo(appID string, now time.Time) error {
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
@@ -124,7 +398,7 @@ func (d *DeisCmd) AppInfo(appID string) error {
 
 	d.Println()
 	// print the app processes
-	if err = d.PsList(app.ID, defaultLimit); err != nil {
+	if err = d.PsList(app.ID, defaultLimit, now); err != nil {
 		return err
 	}
 
@@ -183,29 +457,96 @@ func (d *DeisCmd) AppLogs(appID string, lines int) error {
 	return nil
 }
 
-// AppRun runs a one time command in the app.
-func (d *DeisCmd) AppRun(appID, command string) error {
+// AppRun runs a one time command in the app. If the command exits with a non-zero status,
+// an ExitCodeError carrying that status is returned.
+func (d *DeisCmd) AppRun(appID, command string, timeout time.Duration) error {
+	return d.doAppRun(appID, command, command, timeout)
+}
+
+// AppRunScript runs a local script, or stdin if script is "-", in the app with the given
+// arguments and environment overrides.
+func (d *DeisCmd) AppRunScript(appID, script string, args, env []string, timeout time.Duration) error {
+	var contents []byte
+	var err error
+	name := filepath.Base(script)
+
+	if script == "-" {
+		name = "stdin"
+		contents, err = ioutil.ReadAll(d.WIn)
+	} else {
+		contents, err = ioutil.ReadFile(script)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	command, err := scriptCommand(contents, args, env)
+	if err != nil {
+		return err
+	}
+
+	name = strings.Join(append([]string{name}, args...), " ")
+	return d.doAppRun(appID, command, name, timeout)
+}
+
+func (d *DeisCmd) doAppRun(appID, command, name string, timeout time.Duration) error {
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
 		return err
 	}
 
-	d.Printf("Running '%s'...\n", command)
+	if timeout > 0 {
+		s.Client.HTTPClient.Timeout = timeout
+	}
+
+	d.PrintErrf("Running '%s'...\n", name)
 
 	out, err := apps.Run(s.Client, appID, command)
-	if d.checkAPICompatibility(s.Client, err) != nil {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("'%s' did not finish within %s", name, timeout)
+	} else if d.checkAPICompatibility(s.Client, err) != nil {
 		return err
 	}
 
+	// The controller returns the command's stdout and stderr combined, so the output
+	// is sent to the writer matching the command's exit status.
 	if out.ReturnCode == 0 {
 		d.Print(out.Output)
-	} else {
-		d.PrintErr(out.Output)
+		return nil
 	}
 
-	os.Exit(out.ReturnCode)
-	return nil
+	d.PrintErr(out.Output)
+	return ExitCodeError{Code: out.ReturnCode}
+}
+
+// scriptCommand builds a shell command which writes the script to a temporary file inside
+// the container and executes it. The script is base64 encoded so it doesn't need escaping.
+func scriptCommand(script []byte, args, env []string) (string, error) {
+	envMap, err := parseConfig(env)
+	if err != nil {
+		return "", err
+	}
+
+	var run []string
+	for _, key := range sortKeys(envMap) {
+		run = append(run, key+"="+shellQuote(envMap[key].(string)))
+	}
+
+	run = append(run, `"$f"`)
+	for _, arg := range args {
+		run = append(run, shellQuote(arg))
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(script)
+	return fmt.Sprintf(`f=$(mktemp) && echo %s | base64 -d > "$f" && chmod +x "$f" && %s`,
+		encoded, strings.Join(run, " ")), nil
+}
+
+// shellQuote single quotes a string for use as a shell word.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
 }
 
 // AppDestroy destroys an app.
@@ -278,6 +619,143 @@ func (d *DeisCmd) AppTransfer(appID, username string) error {
 	return nil
 }
 
+// pricesFile stores the prices used by apps:cost.
+const pricesFile = "prices"
+
+// hoursPerMonth is the average number of hours in a month.
+const hoursPerMonth = 730
+
+// prices are the costs of resources used to estimate what an app costs. A price that was
+// never given is nil, while a price of zero makes a resource free.
+type prices struct {
+	Memory *float64 `json:"memory,omitempty"`
+	CPU    *float64 `json:"cpu,omitempty"`
+}
+
+// AppCost estimates how much an app costs per month from the number of processes it is
+// scaled to, its limits and the price of a GiB of memory and a vCPU per hour. Prices that are
+// given are saved for later estimates; prices that are nil are read from the saved ones.
+func (d *DeisCmd) AppCost(appID string, memoryPrice, cpuPrice *float64) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	var p prices
+	if err = loadState(d.ConfigFile, pricesFile, &p); err != nil {
+		return err
+	}
+
+	if memoryPrice != nil || cpuPrice != nil {
+		if memoryPrice != nil {
+			p.Memory = memoryPrice
+		}
+		if cpuPrice != nil {
+			p.CPU = cpuPrice
+		}
+
+		if err = saveState(d.ConfigFile, pricesFile, p); err != nil {
+			return err
+		}
+	}
+
+	if p.Memory == nil && p.CPU == nil {
+		return errors.New("No prices configured, set them with 'deis apps:cost --memory-price=<price> --cpu-price=<price>'")
+	}
+
+	var memory, cpu float64
+	if p.Memory != nil {
+		memory = *p.Memory
+	}
+	if p.CPU != nil {
+		cpu = *p.CPU
+	}
+
+	structure, err := appStructure(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	cfg, err := config.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	d.Printf("=== %s Estimated Monthly Cost\n\n", appID)
+
+	var types []string
+	for psType, count := range structure {
+		if count > 0 {
+			types = append(types, psType)
+		}
+	}
+	sort.Strings(types)
+
+	if len(types) == 0 {
+		d.Println("No processes found.")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(d.WOut)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderLine(true)
+	table.SetHeader([]string{"Type", "Processes", "Memory", "CPU", "Monthly Cost"})
+
+	total, unlimited := 0.0, false
+	for _, psType := range types {
+		count := float64(structure[psType])
+		row := []string{psType, strconv.Itoa(structure[psType])}
+		cost := 0.0
+
+		if quantity, found := limitQuantity(cfg.Memory, psType, "memory"); found {
+			cost += count * float64(quantity) / (1 << 30) * memory * hoursPerMonth
+			row = append(row, displayLimit(formatQuantity(quantity, "memory"), "memory"))
+		} else {
+			unlimited = true
+			row = append(row, "Unlimited")
+		}
+
+		if quantity, found := limitQuantity(cfg.CPU, psType, "cpu"); found {
+			cost += count * float64(quantity) / 1000 * cpu * hoursPerMonth
+			row = append(row, displayLimit(formatQuantity(quantity, "cpu"), "cpu"))
+		} else {
+			unlimited = true
+			row = append(row, "Unlimited")
+		}
+
+		total += cost
+		table.Append(append(row, fmt.Sprintf("$%.2f", cost)))
+	}
+	table.Append([]string{"Total", "", "", "", fmt.Sprintf("$%.2f", total)})
+	table.Render()
+
+	d.Printf("\nPrices: $%g per GiB-hour of memory, $%g per vCPU-hour.\n", memory, cpu)
+	if unlimited {
+		d.Println("Resources without a limit are not included in the estimate.")
+	}
+
+	return nil
+}
+
+// limitQuantity returns the limit a process type has for a resource, ignoring the request
+// if the limit is a request/limit pair.
+func limitQuantity(limits map[string]interface{}, procType, limitType string) (int64, bool) {
+	limit, found := limits[procType]
+	if !found || limit == nil {
+		return 0, false
+	}
+
+	parts := strings.Split(fmt.Sprintf("%v", limit), "/")
+	quantity, err := parseQuantity(parts[len(parts)-1], limitType)
+	if err != nil {
+		return 0, false
+	}
+
+	return quantity, true
+}
+
 const noDomainAssignedMsg = "No domain assigned to %s"
 
 // appURL grabs the first domain an app has and returns this.
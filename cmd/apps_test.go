@@ -6,9 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
+	"github.com/deis/controller-sdk-go/api"
 
 	"github.com/deis/workflow-cli/pkg/git"
 	"github.com/deis/workflow-cli/pkg/testutil"
@@ -61,7 +65,7 @@ func TestAppsList(t *testing.T) {
 		}`)
 	})
 
-	err = cmdr.AppsList(-1)
+	err = cmdr.AppsList(-1, "", "", time.Time{}, nil, "", false)
 	assert.NoErr(t, err)
 	assert.Equal(t, b.String(), `=== Apps
 lorem-ipsum
@@ -100,13 +104,184 @@ func TestAppsListLimit(t *testing.T) {
 		}`)
 	})
 
-	err = cmdr.AppsList(1)
+	err = cmdr.AppsList(1, "", "", time.Time{}, nil, "", false)
 	assert.NoErr(t, err)
 	assert.Equal(t, b.String(), `=== Apps (1 of 2)
 lorem-ipsum
 `, "output")
 }
 
+func TestAppsListFilter(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 5,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"id": "pay-api", "owner": "alice", "created": "2016-03-01T00:00:00UTC", "updated": "2016-06-01T00:00:00UTC"},
+				{"id": "pay-web", "owner": "bob", "created": "2016-01-01T00:00:00UTC", "updated": "2016-05-01T00:00:00UTC"},
+				{"id": "blog", "owner": "alice", "created": "2015-06-01T00:00:00UTC", "updated": "2016-07-01T00:00:00UTC"},
+				{"id": "pay-worker", "owner": "alice", "created": "2016-02-01T00:00:00UTC", "updated": "2016-04-01T00:00:00UTC"},
+				{"id": "legacy", "owner": "carol", "created": "unknown", "updated": "2016-03-15T00:00:00UTC"}
+			]
+		}`)
+	})
+
+	for app, env := range map[string]string{"pay-api": "prod", "pay-worker": "staging"} {
+		env := env
+		server.Mux.HandleFunc("/v2/apps/"+app+"/config/", func(w http.ResponseWriter, r *http.Request) {
+			testutil.SetHeaders(w)
+			fmt.Fprintf(w, `{"tags": {"env": "%s"}}`, env)
+		})
+	}
+
+	cases := []struct {
+		results       int
+		owner         string
+		name          string
+		createdBefore time.Time
+		tags          []string
+		sortBy        string
+		reverse       bool
+		expected      string
+	}{
+		{-1, "alice", "", time.Time{}, nil, "", false, "=== Apps\npay-api\nblog\npay-worker\n"},
+		{-1, "", "pay-*", time.Time{}, nil, "name", false, "=== Apps\npay-api\npay-web\npay-worker\n"},
+		{-1, "alice", "pay-*", time.Time{}, []string{"env=prod"}, "", false, "=== Apps\npay-api\n"},
+		{-1, "", "", time.Date(2016, time.February, 15, 0, 0, 0, 0, time.UTC), nil, "created", false,
+			"=== Apps\nblog\npay-web\npay-worker\n"},
+		{2, "", "", time.Time{}, nil, "updated", true, "=== Apps (2 of 5)\nblog\npay-api\n"},
+		{-1, "", "", time.Time{}, nil, "owner", false, "=== Apps\npay-api\nblog\npay-worker\npay-web\nlegacy\n"},
+	}
+
+	for _, c := range cases {
+		b.Reset()
+		err = cmdr.AppsList(c.results, c.owner, c.name, c.createdBefore, c.tags, c.sortBy, c.reverse)
+		assert.NoErr(t, err)
+		assert.Equal(t, b.String(), c.expected, "output")
+	}
+
+	err = cmdr.AppsList(-1, "", "pay-[", time.Time{}, nil, "", false)
+	assert.Equal(t, err.Error(), "pay-[ is not a valid name pattern", "error")
+
+	err = cmdr.AppsList(-1, "", "", time.Time{}, nil, "size", false)
+	assert.Equal(t, err.Error(), "cannot sort by size, must be one of name, owner, created or updated", "error")
+
+	err = cmdr.AppsList(-1, "", "", time.Time{}, nil, "", true)
+	assert.Equal(t, err.Error(), "--reverse needs --sort, such as --sort=name", "error")
+}
+
+func TestAppsStatus(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 4,
+			"next": null,
+			"previous": null,
+			"results": [{"id": "foo"}, {"id": "bar"}, {"id": "qux"}, {"id": "quux"}]
+		}`)
+	})
+
+	// responses maps each app to its processes, latest release, settings, TLS settings and
+	// domain count.
+	responses := map[string][5]string{
+		"foo": {
+			`[{"type": "web", "name": "foo-web-1", "state": "up"}, {"type": "web", "name": "foo-web-2", "state": "up"}]`,
+			`[{"version": 3}]`, `{"routable": true, "maintenance": false}`, `{"https_enforced": true}`, "2",
+		},
+		"bar": {
+			`[{"type": "web", "name": "bar-web-1", "state": "up"}, {"type": "worker", "name": "bar-worker-1", "state": "crashed"}]`,
+			`[{"version": 7}]`, `{"routable": false, "maintenance": true}`, `{}`, "1",
+		},
+	}
+	responses["qux"] = responses["foo"]
+	responses["quux"] = responses["foo"]
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	for _, app := range []string{"foo", "bar", "qux", "quux", "baz"} {
+		app := app
+		response, found := responses[app]
+		server.Mux.HandleFunc("/v2/apps/"+app+"/", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+			time.Sleep(5 * time.Millisecond)
+
+			testutil.SetHeaders(w)
+			if !found {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			switch strings.Split(r.URL.Path, "/")[4] {
+			case "pods":
+				fmt.Fprintf(w, `{"count": 2, "results": %s}`, response[0])
+			case "releases":
+				fmt.Fprintf(w, `{"count": 1, "results": %s}`, response[1])
+			case "settings":
+				fmt.Fprint(w, response[2])
+			case "tls":
+				fmt.Fprint(w, response[3])
+			case "domains":
+				fmt.Fprintf(w, `{"count": %s, "results": [{"domain": "%s.example.com"}]}`, response[4], app)
+			}
+		})
+	}
+
+	// more apps than workers, so the workers are kept busy and have to share them.
+	workers := 2
+	err = cmdr.AppsStatus(workers)
+	assert.NoErr(t, err)
+
+	assert.True(t, maxInFlight <= workers, fmt.Sprintf("%d apps queried at once by %d workers", maxInFlight, workers))
+	assert.True(t, maxInFlight > 1, "apps queried concurrently")
+	// unhealthy apps are listed first, then the others in the order the controller lists them.
+	assert.Equal(t, b.String(), `  App  | Processes | Release | Routable | Maintenance |  HTTPS   | Domains |        Status         
++------+-----------+---------+----------+-------------+----------+---------+----------------------+
+  bar  | 1/2 up    | v7      | no       | on          | optional | 1       | UNHEALTHY: 1 crashed  
+  foo  | 2/2 up    | v3      | yes      | off         | enforced | 2       | OK                    
+  qux  | 2/2 up    | v3      | yes      | off         | enforced | 2       | OK                    
+  quux | 2/2 up    | v3      | yes      | off         | enforced | 2       | OK                    
+
+4 app(s), 1 unhealthy
+`, "output")
+
+	// an app which can't be queried is unhealthy.
+	s, err := settings.Load(cf)
+	assert.NoErr(t, err)
+	status := fetchAppStatus(s.Client, "baz", s.Limit)
+	assert.True(t, status.err != nil, "error")
+	assert.Equal(t, len(status.problems), 1, "problems")
+}
+
 func TestAppsInfo(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -179,7 +354,7 @@ func TestAppsInfo(t *testing.T) {
 		url = fmt.Sprintf(noDomainAssignedMsg, "lorem-ipsum")
 	}
 
-	err = cmdr.AppInfo("lorem-ipsum")
+	err = cmdr.AppInfo("lorem-ipsum", time.Date(2016, time.August, 22, 18, 0, 0, 0, time.UTC))
 	assert.NoErr(t, err)
 	assert.Equal(t, b.String(), `=== lorem-ipsum Application
 updated:  2016-08-22T17:40:16Z
@@ -191,7 +366,7 @@ id:       lorem-ipsum
 
 === lorem-ipsum Processes
 --- cmd:
-lorem-ipsum-cmd-1911796442-48b58 up (v2)
+lorem-ipsum-cmd-1911796442-48b58 up (v2, started 17m ago)
 
 === lorem-ipsum Domains
 lorem-ipsum
@@ -232,6 +407,74 @@ done in 0s
 `, "output")
 }
 
+func TestAppRun(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b, e bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, WErr: &e, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/foo/run", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"exit_code": 0, "output": "migrated\n"}`)
+	})
+
+	err = cmdr.AppRun("foo", "./manage.py migrate", 0)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "migrated\n", "output")
+	assert.Equal(t, e.String(), "Running './manage.py migrate'...\n", "error output")
+
+	server.Mux.HandleFunc("/v2/apps/bar/run", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"exit_code": 3, "output": "no such table\n"}`)
+	})
+	b.Reset()
+	e.Reset()
+
+	err = cmdr.AppRun("bar", "./manage.py migrate", 0)
+	assert.Equal(t, err, ExitCodeError{Code: 3}, "error")
+	assert.Equal(t, b.String(), "", "output")
+	assert.Equal(t, e.String(), "Running './manage.py migrate'...\nno such table\n", "error output")
+}
+
+func TestScriptCommand(t *testing.T) {
+	t.Parallel()
+
+	command, err := scriptCommand([]byte("#!/bin/sh\necho $1\n"), []string{"it's"}, []string{"B=2", "A=x y"})
+	assert.NoErr(t, err)
+	assert.Equal(t, command, `f=$(mktemp) && echo IyEvYmluL3NoCmVjaG8gJDEK | base64 -d > "$f" && chmod +x "$f" && A='x y' B='2' "$f" 'it'\''s'`, "command")
+
+	_, err = scriptCommand([]byte("true"), nil, []string{"NOTVALID"})
+	assert.Equal(t, err.Error(), "'NOTVALID' does not match the pattern 'key=var', ex: MODE=test\n", "error")
+}
+
+func TestAppRunScript(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b, e bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, WErr: &e, WIn: strings.NewReader("echo fixed\n"), ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/foo/run", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		testutil.AssertBody(t, api.AppRunRequest{
+			Command: `f=$(mktemp) && echo ZWNobyBmaXhlZAo= | base64 -d > "$f" && chmod +x "$f" && "$f" '--all'`,
+		}, r)
+		fmt.Fprintf(w, `{"exit_code": 0, "output": "fixed\n"}`)
+	})
+
+	err = cmdr.AppRunScript("foo", "-", []string{"--all"}, nil, 0)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "fixed\n", "output")
+	assert.Equal(t, e.String(), "Running 'stdin --all'...\n", "error output")
+}
+
 func TestAppTransfer(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -321,3 +564,77 @@ func TestRemoteExists(t *testing.T) {
 deis git:remote --force --remote deis --app foo`,
 		"output")
 }
+
+func TestAppCost(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	// crashed or terminating processes aren't listed, only what the app is scaled to counts.
+	server.Mux.HandleFunc("/v2/apps/foo/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"id": "foo", "owner": "bar", "structure": {"web": 2, "worker": 1, "cron": 0}}`)
+	})
+	server.Mux.HandleFunc("/v2/apps/foo/config/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"owner": "bar",
+			"app": "foo",
+			"values": {},
+			"memory": {"web": "256M/1G", "worker": "512M"},
+			"cpu": {"web": "500m"},
+			"tags": {},
+			"registry": {},
+			"created": "2014-01-01T00:00:00UTC",
+			"updated": "2014-01-01T00:00:00UTC",
+			"uuid": "de1bf5b5-4a72-4f94-a10c-d2a3741cdf75"
+		}`)
+	})
+
+	err = cmdr.AppCost("foo", nil, nil)
+	assert.Equal(t, err.Error(), "No prices configured, set them with 'deis apps:cost --memory-price=<price> --cpu-price=<price>'", "error")
+
+	memoryPrice, cpuPrice := 0.01, 0.04
+	err = cmdr.AppCost("foo", &memoryPrice, &cpuPrice)
+	assert.NoErr(t, err)
+	expected := `=== foo Estimated Monthly Cost
+
+   Type  | Processes | Memory |    CPU    | Monthly Cost  
++--------+-----------+--------+-----------+--------------+
+  web    | 2         | 1024M  | 500m      | $43.80        
+  worker | 1         | 512M   | Unlimited | $3.65         
+  Total  |           |        |           | $47.45        
+
+Prices: $0.01 per GiB-hour of memory, $0.04 per vCPU-hour.
+Resources without a limit are not included in the estimate.
+`
+	assert.Equal(t, b.String(), expected, "output")
+
+	// the prices are saved for the next estimate.
+	b.Reset()
+	err = cmdr.AppCost("foo", nil, nil)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), expected, "output")
+
+	// a price of zero makes a resource free.
+	b.Reset()
+	free := 0.0
+	err = cmdr.AppCost("foo", nil, &free)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== foo Estimated Monthly Cost
+
+   Type  | Processes | Memory |    CPU    | Monthly Cost  
++--------+-----------+--------+-----------+--------------+
+  web    | 2         | 1024M  | 500m      | $14.60        
+  worker | 1         | 512M   | Unlimited | $3.65         
+  Total  |           |        |           | $18.25        
+
+Prices: $0.01 per GiB-hour of memory, $0 per vCPU-hour.
+Resources without a limit are not included in the estimate.
+`, "output")
+}
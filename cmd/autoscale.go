@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+
 	"github.com/deis/controller-sdk-go/api"
 	"github.com/deis/controller-sdk-go/appsettings"
+	"github.com/deis/controller-sdk-go/config"
+	"github.com/deis/controller-sdk-go/ps"
+	"github.com/olekukonko/tablewriter"
 )
 
 // AutoscaleList tells the informations about app's autoscale status
@@ -20,13 +27,64 @@ func (d *DeisCmd) AutoscaleList(appID string) error {
 
 	d.Printf("=== %s Autoscale\n\n", appID)
 
-	if appSettings.Autoscale == nil {
+	if len(appSettings.Autoscale) == 0 {
 		d.Println("No autoscale rules found.")
-	} else {
-		for process, kv := range appSettings.Autoscale {
-			d.Println("--- " + process + ":")
-			d.Println(*kv)
+		return nil
+	}
+
+	processes, _, err := ps.List(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	replicas := make(map[string]int)
+	for _, pod := range processes {
+		replicas[pod.Type]++
+	}
+
+	var processTypes []string
+	for processType := range appSettings.Autoscale {
+		processTypes = append(processTypes, processType)
+	}
+	sort.Strings(processTypes)
+
+	table := tablewriter.NewWriter(d.WOut)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderLine(true)
+	table.SetHeader([]string{"Type", "Min", "Max", "Target CPU", "Current"})
+	for _, processType := range processTypes {
+		rule := appSettings.Autoscale[processType]
+		if rule == nil {
+			continue
 		}
+
+		table.Append([]string{
+			processType,
+			strconv.Itoa(rule.Min),
+			strconv.Itoa(rule.Max),
+			fmt.Sprintf("%d%%", rule.CPUPercent),
+			strconv.Itoa(replicas[processType]),
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+// validateAutoscale checks autoscale options before they are sent to the controller.
+func validateAutoscale(min, max, CPUPercent int) error {
+	if min < 1 {
+		return fmt.Errorf("min replicas must be at least 1, got %d", min)
+	}
+
+	if min > max {
+		return fmt.Errorf("min replicas (%d) must not be greater than max replicas (%d)", min, max)
+	}
+
+	if CPUPercent < 1 || CPUPercent > 100 {
+		return fmt.Errorf("target CPU must be between 1 and 100 percent, got %d", CPUPercent)
 	}
 
 	return nil
@@ -34,12 +92,27 @@ func (d *DeisCmd) AutoscaleList(appID string) error {
 
 // AutoscaleSet sets autoscale options for the app.
 func (d *DeisCmd) AutoscaleSet(appID string, processType string, min int, max int, CPUPercent int) error {
+	if err := validateAutoscale(min, max, CPUPercent); err != nil {
+		return err
+	}
+
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
 		return err
 	}
 
+	cfg, err := config.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	// The CPU target is a percentage of the CPU limit, so without one there is nothing to scale on.
+	if _, found := cfg.CPU[processType]; !found {
+		d.PrintErrf("Warning: process type %s has no CPU limit, so CPU based autoscaling will not work.\n", processType)
+		d.PrintErrf("Set one with 'deis limits:set --cpu %s=<limit> -a %s'.\n", processType, appID)
+	}
+
 	d.Printf("Applying autoscale settings for process type %s on %s... ", processType, appID)
 
 	quit := progress(d.WOut)
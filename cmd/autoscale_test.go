@@ -26,16 +26,39 @@ func TestAutoscaleList(t *testing.T) {
 		fmt.Fprintf(w, `{
 			"owner": "elrond",
 			"app": "rivendell",
-			"autoscale": {"cmd": {"min": 3, "max": 8, "cpu_percent": 40}},
+			"autoscale": {
+				"worker": {"min": 1, "max": 4, "cpu_percent": 80},
+				"cmd": {"min": 3, "max": 8, "cpu_percent": 40}
+			},
 			"created": "2014-01-01T00:00:00UTC",
 			"updated": "2014-01-01T00:00:00UTC",
 			"uuid": "de1bf5b5-4a72-4f94-a10c-d2a3741cdf75"
 		}`)
 	})
 
+	server.Mux.HandleFunc("/v2/apps/rivendell/pods/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 3,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"release": "v2", "type": "cmd", "name": "rivendell-cmd-4084101150-c871y", "state": "up"},
+				{"release": "v2", "type": "cmd", "name": "rivendell-cmd-4084101150-d982z", "state": "up"},
+				{"release": "v2", "type": "cmd", "name": "rivendell-cmd-4084101150-e093a", "state": "up"}
+			]
+		}`)
+	})
+
 	err = cmdr.AutoscaleList("rivendell")
 	assert.NoErr(t, err)
-	assert.Equal(t, b.String(), "=== rivendell Autoscale\n\n--- cmd:\nMin Replicas: 3\nMax Replicas: 8\nCPU: 40%\n", "output")
+	assert.Equal(t, b.String(), `=== rivendell Autoscale
+
+   Type  | Min | Max | Target CPU | Current  
++--------+-----+-----+------------+---------+
+  cmd    | 3   | 8   | 40%        | 3        
+  worker | 1   | 4   | 80%        | 0        
+`, "output")
 
 	server.Mux.HandleFunc("/v2/apps/mordor/settings/", func(w http.ResponseWriter, r *http.Request) {
 		testutil.SetHeaders(w)
@@ -62,7 +85,8 @@ func TestAutoscaleSet(t *testing.T) {
 	}
 	defer server.Close()
 	var b bytes.Buffer
-	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+	var e bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, WErr: &e, ConfigFile: cf}
 
 	server.Mux.HandleFunc("/v2/apps/lothlorien/settings/", func(w http.ResponseWriter, r *http.Request) {
 		testutil.SetHeaders(w)
@@ -77,9 +101,62 @@ func TestAutoscaleSet(t *testing.T) {
 		fmt.Fprintf(w, `{}`)
 	})
 
+	server.Mux.HandleFunc("/v2/apps/lothlorien/config/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"owner": "galadriel",
+			"app": "lothlorien",
+			"values": {},
+			"memory": {},
+			"cpu": {"cmd": "500m"},
+			"tags": {},
+			"registry": {},
+			"created": "2014-01-01T00:00:00UTC",
+			"updated": "2014-01-01T00:00:00UTC",
+			"uuid": "de1bf5b5-4a72-4f94-a10c-d2a3741cdf75"
+		}`)
+	})
+
 	err = cmdr.AutoscaleSet("lothlorien", "cmd", 3, 8, 40)
 	assert.NoErr(t, err)
 	assert.Equal(t, testutil.StripProgress(b.String()), "Applying autoscale settings for process type cmd on lothlorien... done\n", "output")
+	assert.Equal(t, e.String(), "", "stderr")
+
+	server.Mux.HandleFunc("/v2/apps/moria/config/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"owner": "durin",
+			"app": "moria",
+			"values": {},
+			"memory": {},
+			"cpu": {},
+			"tags": {},
+			"registry": {},
+			"created": "2014-01-01T00:00:00UTC",
+			"updated": "2014-01-01T00:00:00UTC",
+			"uuid": "de1bf5b5-4a72-4f94-a10c-d2a3741cdf75"
+		}`)
+	})
+	server.Mux.HandleFunc("/v2/apps/moria/settings/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{}`)
+	})
+
+	b.Reset()
+	err = cmdr.AutoscaleSet("moria", "cmd", 1, 2, 50)
+	assert.NoErr(t, err)
+	assert.Equal(t, e.String(), `Warning: process type cmd has no CPU limit, so CPU based autoscaling will not work.
+Set one with 'deis limits:set --cpu cmd=<limit> -a moria'.
+`, "stderr")
+
+	err = cmdr.AutoscaleSet("moria", "cmd", 5, 2, 50)
+	assert.Equal(t, err.Error(), "min replicas (5) must not be greater than max replicas (2)", "error")
+
+	err = cmdr.AutoscaleSet("moria", "cmd", 0, 2, 50)
+	assert.Equal(t, err.Error(), "min replicas must be at least 1, got 0", "error")
+
+	err = cmdr.AutoscaleSet("moria", "cmd", 1, 2, 150)
+	assert.Equal(t, err.Error(), "target CPU must be between 1 and 100 percent, got 150", "error")
 }
 
 func TestAutoscaleUnset(t *testing.T) {
@@ -1,16 +1,34 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
 
 	"github.com/deis/controller-sdk-go"
+	"github.com/deis/controller-sdk-go/api"
+	"github.com/deis/controller-sdk-go/apps"
 	"github.com/deis/controller-sdk-go/certs"
+	"github.com/deis/controller-sdk-go/domains"
 	dtime "github.com/deis/controller-sdk-go/pkg/time"
+	"github.com/deis/workflow-cli/pkg/acme"
 	"github.com/deis/workflow-cli/settings"
 )
 
@@ -52,32 +70,10 @@ func (d *DeisCmd) CertsList(results int, now time.Time) error {
 		if cert.Expires.Time != nil {
 			expires = cert.Expires.Format(dateFormat)
 
-			if cert.Expires.Time.Before(now) {
+			if left := cert.Expires.Time.Sub(now); left <= 0 {
 				expires += " (expired)"
 			} else {
-				// Ghetto solution
-				expires += " (in"
-				year := cert.Expires.Time.Year() - now.Year()
-				month := cert.Expires.Time.Month() - now.Month()
-				day := cert.Expires.Time.Day() - now.Day()
-
-				if year > 0 {
-					expires += fmt.Sprintf(" %d year", year)
-					if year > 1 {
-						expires += "s"
-					}
-				} else if month > 0 {
-					expires += fmt.Sprintf(" %d month", month)
-					if month > 1 {
-						expires += "s"
-					}
-				} else if day != 0 {
-					expires += fmt.Sprintf(" %d day", day)
-					if day > 1 {
-						expires += "s"
-					}
-				}
-				expires += ")"
+				expires += " (in " + longDuration(left) + ")"
 			}
 		}
 
@@ -97,14 +93,58 @@ func (d *DeisCmd) CertsList(results int, now time.Time) error {
 	return nil
 }
 
-// CertAdd adds a cert to the controller.
-func (d *DeisCmd) CertAdd(cert string, key string, name string) error {
+// longDuration formats a duration in its largest whole unit, such as 3 days, 1 month or
+// 2 years. Months are 30 days and years 365 days long.
+func longDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+
+	for _, unit := range units {
+		if n := int(d / unit.size); n > 0 {
+			if n > 1 {
+				return fmt.Sprintf("%d %ss", n, unit.name)
+			}
+			return fmt.Sprintf("%d %s", n, unit.name)
+		}
+	}
+
+	return "less than a minute"
+}
+
+// CertAdd adds a cert to the controller. The cert and key are checked locally first, and
+// a summary of the cert is printed. With force, a cert which fails the checks is added
+// anyway, such as one which isn't valid yet.
+func (d *DeisCmd) CertAdd(cert, key, name string, force bool, now time.Time) error {
 	s, err := settings.Load(d.ConfigFile)
 
 	if err != nil {
 		return err
 	}
 
+	certPEM, err := ioutil.ReadFile(cert)
+	if err != nil {
+		return err
+	}
+
+	keyPEM, err := ioutil.ReadFile(key)
+	if err != nil {
+		return err
+	}
+
+	summary, err := inspectCert(certPEM, keyPEM, now, force)
+	if err != nil {
+		return fmt.Errorf("%s and %s are not a valid certificate and key: %v", cert, key, err)
+	}
+	d.Println(summary)
+
 	d.Print("Adding SSL endpoint... ")
 	quit := progress(d.WOut)
 	err = d.doCertAdd(s.Client, cert, key, name)
@@ -119,6 +159,115 @@ func (d *DeisCmd) CertAdd(cert string, key string, name string) error {
 	return nil
 }
 
+// inspectCert checks a PEM encoded certificate chain and its private key the way a TLS
+// server would load them, and describes the certificate. The chain must start with the
+// certificate itself, followed by the intermediates, each signed by the next one. With
+// force, a key which doesn't match or a chain which isn't valid is only warned about.
+func inspectCert(certPEM, keyPEM []byte, now time.Time, force bool) (string, error) {
+	chain, err := parseCertChain(certPEM)
+	if err != nil {
+		return "", err
+	}
+
+	var problems []error
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		problems = append(problems, err)
+	}
+
+	for i, c := range chain {
+		if now.After(c.NotAfter) {
+			problems = append(problems, fmt.Errorf("%s expired on %s", c.Subject.CommonName, c.NotAfter.Format(dateFormat)))
+		}
+		if now.Before(c.NotBefore) {
+			problems = append(problems, fmt.Errorf("%s is not valid until %s", c.Subject.CommonName, c.NotBefore.Format(dateFormat)))
+		}
+
+		if i > 0 {
+			if err := chain[i-1].CheckSignatureFrom(c); err != nil {
+				problems = append(problems, fmt.Errorf("%s is not signed by %s, the chain must list each certificate before its issuer",
+					chain[i-1].Subject.CommonName, c.Subject.CommonName))
+			}
+		}
+	}
+
+	if len(problems) > 0 && !force {
+		return "", problems[0]
+	}
+
+	leaf := chain[0]
+	var warnings []string
+	for _, problem := range problems {
+		warnings = append(warnings, problem.Error())
+	}
+
+	keyType := "Unknown"
+	switch publicKey := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		bits := publicKey.N.BitLen()
+		keyType = fmt.Sprintf("RSA %d bits", bits)
+		if bits < 2048 {
+			warnings = append(warnings, "RSA keys shorter than 2048 bits are rejected by most browsers")
+		}
+	case *ecdsa.PublicKey:
+		keyType = "ECDSA " + publicKey.Curve.Params().Name
+	}
+
+	san := strings.Join(leaf.DNSNames, ",")
+	if san == "" {
+		san = "N/A"
+		warnings = append(warnings, "the certificate has no SubjectAltName, browsers ignore its common name")
+	}
+
+	if len(chain) == 1 && !bytes.Equal(leaf.RawIssuer, leaf.RawSubject) {
+		warnings = append(warnings, fmt.Sprintf("no intermediate certificates are included, clients without %s may not trust the certificate",
+			leaf.Issuer.CommonName))
+	}
+
+	expires := leaf.NotAfter.Format(dateFormat) + " (expired)"
+	if left := leaf.NotAfter.Sub(now); left > 0 {
+		expires = fmt.Sprintf("%s (in %s)", leaf.NotAfter.Format(dateFormat), shortDuration(left))
+	}
+
+	summary := fmt.Sprintf(`Common Name: %s
+SubjectAltName: %s
+Issuer: %s
+Expires: %s
+Key: %s
+Chain: %d certificate(s)`, leaf.Subject.CommonName, san, leaf.Issuer.CommonName, expires, keyType, len(chain))
+
+	for _, warning := range warnings {
+		summary += "\nWarning: " + warning
+	}
+
+	return summary, nil
+}
+
+// parseCertChain parses the certificates in a PEM encoded chain, in order.
+func parseCertChain(certPEM []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, parsed)
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return chain, nil
+}
+
 func (d *DeisCmd) doCertAdd(c *deis.Client, cert string, key string, name string) error {
 	certFile, err := ioutil.ReadFile(cert)
 	if err != nil {
@@ -155,6 +304,78 @@ func (d *DeisCmd) CertRemove(name string) error {
 	return nil
 }
 
+// Exit codes of certs:check, following the conventions of Nagios plugins.
+const (
+	certsCheckOK       = 0
+	certsCheckWarning  = 1
+	certsCheckCritical = 2
+	certsCheckUnknown  = 3
+)
+
+// CertsCheck lists the certs which expired or expire within the given duration. The
+// command fails with a warning exit code if some certs expire soon, and with a critical
+// exit code if some have expired. If the certs can't be listed, it fails with an unknown
+// exit code.
+func (d *DeisCmd) CertsCheck(within time.Duration, now time.Time) error {
+	unknown := func(err error) error {
+		d.Printf("UNKNOWN: %v\n", err)
+		return ExitCodeError{Code: certsCheckUnknown}
+	}
+
+	s, err := settings.Load(d.ConfigFile)
+	if err != nil {
+		return unknown(err)
+	}
+
+	certList, count, err := certs.List(s.Client, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return unknown(err)
+	}
+	if count > len(certList) {
+		if certList, _, err = certs.List(s.Client, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return unknown(err)
+		}
+	}
+
+	var expired, expiring []string
+	for _, cert := range certList {
+		if cert.Expires.Time == nil {
+			continue
+		}
+
+		left := cert.Expires.Time.Sub(now)
+		date := cert.Expires.Format(dateFormat)
+		switch {
+		case left <= 0:
+			expired = append(expired, fmt.Sprintf("%s expired %s ago (%s)", cert.Name, shortDuration(-left), date))
+		case left <= within:
+			expiring = append(expiring, fmt.Sprintf("%s expires in %s (%s)", cert.Name, shortDuration(left), date))
+		}
+	}
+
+	code := certsCheckOK
+	switch {
+	case len(expired) > 0:
+		code = certsCheckCritical
+		d.Printf("CRITICAL: %d cert(s) expired, %d expiring within %s\n", len(expired), len(expiring), shortDuration(within))
+	case len(expiring) > 0:
+		code = certsCheckWarning
+		d.Printf("WARNING: %d cert(s) expiring within %s\n", len(expiring), shortDuration(within))
+	default:
+		d.Printf("OK: no certs expiring within %s\n", shortDuration(within))
+	}
+
+	for _, line := range append(expired, expiring...) {
+		d.Println(line)
+	}
+
+	if code != certsCheckOK {
+		return ExitCodeError{Code: code}
+	}
+
+	return nil
+}
+
 // CertInfo gets info about certficiate
 func (d *DeisCmd) CertInfo(name string) error {
 	s, err := settings.Load(d.ConfigFile)
@@ -220,6 +441,581 @@ func (d *DeisCmd) CertAttach(name, domain string) error {
 	return err
 }
 
+// CertAttachAuto attaches a certificate to every domain of every app the user can see that
+// is covered by the certificate's common name or SubjectAltName. The matches are previewed
+// before anything is attached.
+func (d *DeisCmd) CertAttachAuto(name string, yes bool) error {
+	s, err := settings.Load(d.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := certs.Get(s.Client, name)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	appList, count, err := apps.List(s.Client, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	if count > len(appList) {
+		if appList, _, err = apps.List(s.Client, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+	}
+
+	attached := make(map[string]bool, len(cert.Domains))
+	for _, domain := range cert.Domains {
+		attached[domain] = true
+	}
+
+	names := append([]string{cert.CommonName}, cert.SubjectAltName...)
+	var matches []api.Domain
+	var matchedBy []string
+	for _, app := range appList {
+		domainList, count, err := domains.List(s.Client, app.ID, s.Limit)
+		if d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+		if count > len(domainList) {
+			if domainList, _, err = domains.List(s.Client, app.ID, count); d.checkAPICompatibility(s.Client, err) != nil {
+				return err
+			}
+		}
+
+		for _, domain := range domainList {
+			if attached[domain.Domain] {
+				continue
+			}
+			if match := matchCertName(names, domain.Domain); match != "" {
+				matches = append(matches, domain)
+				matchedBy = append(matchedBy, match)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		d.Printf("No unattached domains match certificate %s\n", name)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(d.WOut)
+	table.SetHeader([]string{"App", "Domain", "Matched By"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderLine(true)
+	for i, domain := range matches {
+		table.Append([]string{domain.App, domain.Domain, matchedBy[i]})
+	}
+	table.Render()
+
+	if !yes {
+		confirm := ""
+		d.Printf("attach certificate %s to %d domain(s)? (y/N): ", name, len(matches))
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			d.PrintErrln("No domains attached")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, domain := range matches {
+		if err = certs.Attach(s.Client, name, domain.Domain); d.checkAPICompatibility(s.Client, err) != nil {
+			d.Printf("%s: could not be attached: %v\n", domain.Domain, err)
+			failed++
+			continue
+		}
+		d.Printf("%s: attached\n", domain.Domain)
+	}
+
+	d.Printf("\nAttached %d, failed %d.\n", len(matches)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("certificate %s could not be attached to %d domain(s)", name, failed)
+	}
+
+	return nil
+}
+
+// CertRotate replaces a certificate with a new one: the new certificate is added, every
+// domain of the old certificate is moved to it, and the old certificate is removed. If a
+// domain can't be moved, the domains moved so far are attached to the old certificate
+// again and the new certificate is removed.
+func (d *DeisCmd) CertRotate(old, cert, key, name string, now time.Time) error {
+	s, err := settings.Load(d.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	certPEM, err := ioutil.ReadFile(cert)
+	if err != nil {
+		return err
+	}
+
+	keyPEM, err := ioutil.ReadFile(key)
+	if err != nil {
+		return err
+	}
+
+	summary, err := inspectCert(certPEM, keyPEM, now, false)
+	if err != nil {
+		return fmt.Errorf("%s and %s are not a valid certificate and key: %v", cert, key, err)
+	}
+
+	oldCert, err := certs.Get(s.Client, old)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", old, now.Format("20060102"))
+	}
+
+	// a domain the new certificate doesn't cover would lose TLS, so nothing is changed.
+	chain, err := parseCertChain(certPEM)
+	if err != nil {
+		return err
+	}
+	names := append([]string{chain[0].Subject.CommonName}, chain[0].DNSNames...)
+	var uncovered []string
+	for _, domain := range oldCert.Domains {
+		if matchCertName(names, domain) == "" {
+			uncovered = append(uncovered, domain)
+		}
+	}
+	if len(uncovered) > 0 {
+		return fmt.Errorf("%s does not cover %s, which %s is attached to", cert, strings.Join(uncovered, ", "), old)
+	}
+
+	d.Println(summary)
+	d.Printf("Adding certificate %s... ", name)
+	_, err = certs.New(s.Client, string(certPEM), string(keyPEM), name)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	d.Println("done")
+
+	var moved []string
+	for _, domain := range oldCert.Domains {
+		if err = certs.Detach(s.Client, old, domain); d.checkAPICompatibility(s.Client, err) != nil {
+			d.Printf("%s: could not be detached from %s: %v\n", domain, old, err)
+			return d.rollbackCertRotate(s.Client, old, name, moved, 0, err)
+		}
+
+		if err = certs.Attach(s.Client, name, domain); d.checkAPICompatibility(s.Client, err) != nil {
+			d.Printf("%s: could not be attached to %s: %v\n", domain, name, err)
+			// the domain has no certificate now, so it is restored along with the moved ones.
+			unrestored := 0
+			if rerr := certs.Attach(s.Client, old, domain); d.checkAPICompatibility(s.Client, rerr) != nil {
+				d.Printf("%s: could not be attached to %s again: %v\n", domain, old, rerr)
+				unrestored++
+			}
+			return d.rollbackCertRotate(s.Client, old, name, moved, unrestored, err)
+		}
+
+		d.Printf("%s: moved to %s\n", domain, name)
+		moved = append(moved, domain)
+	}
+
+	d.Printf("Removing certificate %s... ", old)
+	err = certs.Delete(s.Client, old)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return fmt.Errorf("all domains were moved to %s, but %s could not be removed: %v", name, old, err)
+	}
+	d.Println("done")
+
+	return nil
+}
+
+// rollbackCertRotate undoes a failed certs:rotate by moving the given domains back to the
+// old certificate and removing the new one. unrestored counts the domains already left
+// without a certificate. The returned error wraps the cause of the failure and says whether
+// the rollback was complete.
+func (d *DeisCmd) rollbackCertRotate(c *deis.Client, old, name string, moved []string, unrestored int,
+	cause error) error {
+	d.Println("Rolling back...")
+
+	failed := 0
+	for _, domain := range moved {
+		err := certs.Detach(c, name, domain)
+		if d.checkAPICompatibility(c, err) == nil {
+			err = certs.Attach(c, old, domain)
+		}
+		if d.checkAPICompatibility(c, err) != nil {
+			d.Printf("%s: could not be moved back to %s: %v\n", domain, old, err)
+			failed++
+			continue
+		}
+		d.Printf("%s: moved back to %s\n", domain, old)
+	}
+
+	// the new certificate is kept if a domain still uses it.
+	if failed == 0 {
+		if err := certs.Delete(c, name); d.checkAPICompatibility(c, err) != nil {
+			d.Printf("Certificate %s could not be removed: %v\n", name, err)
+			failed++
+		}
+	}
+
+	if failed+unrestored > 0 {
+		return fmt.Errorf("Rotation of %s failed and could not be fully rolled back: %v", old, cause)
+	}
+
+	return fmt.Errorf("Rotation of %s failed and was rolled back: %v", old, cause)
+}
+
+// acmePollInterval is how often certs:issue checks whether a dns-01 TXT record resolves.
+const acmePollInterval = 10 * time.Second
+
+// acmeAccount is an account registered with an ACME directory. Accounts are saved in the
+// "acme-accounts" state file, keyed by directory URL.
+type acmeAccount struct {
+	Key string `json:"key"`
+	URI string `json:"uri"`
+}
+
+// CertIssue obtains a certificate for domains from an ACME directory such as Let's Encrypt,
+// then adds it to the controller and attaches it to each domain. Control of each domain is
+// proven with an http-01 challenge, answered by a server listening on listen, or a dns-01
+// challenge, for which the user creates a TXT record. The user is asked to agree to the
+// directory's terms of service when registering an account, unless agreeTOS is set.
+func (d *DeisCmd) CertIssue(domainList []string, name, directory, challenge, email, listen string,
+	agreeTOS bool, timeout time.Duration, now time.Time) error {
+	if challenge != "http-01" && challenge != "dns-01" {
+		return fmt.Errorf("unsupported challenge %s, must be http-01 or dns-01", challenge)
+	}
+
+	for _, domain := range domainList {
+		if err := validateDomain(domain); err != nil {
+			return err
+		}
+		if strings.HasPrefix(domain, "*.") {
+			return fmt.Errorf("ACME can't issue wildcard certificates such as %s", domain)
+		}
+	}
+
+	s, err := settings.Load(d.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = strings.Replace(domainList[0], ".", "-", -1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := d.acmeClient(ctx, directory, email, agreeTOS)
+	if err != nil {
+		return err
+	}
+
+	// served receives the error which stopped the http-01 server. It stays nil for dns-01.
+	var served chan error
+	responder := &http01Responder{responses: make(map[string]string)}
+	if challenge == "http-01" {
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return fmt.Errorf("could not listen on %s for http-01 challenges: %v", listen, err)
+		}
+		defer listener.Close()
+
+		served = make(chan error, 1)
+		go func() {
+			served <- http.Serve(listener, responder)
+			cancel()
+		}()
+	}
+
+	order, err := client.AuthorizeOrder(ctx, domainList)
+	if err != nil {
+		return fmt.Errorf("could not order a certificate for %s: %v", strings.Join(domainList, ", "), err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		domain, err := d.acmeAuthorize(ctx, client, authzURL, challenge, responder)
+		if err != nil {
+			select {
+			case serveErr := <-served:
+				return fmt.Errorf("could not serve http-01 challenges on %s: %v", listen, serveErr)
+			default:
+			}
+			return fmt.Errorf("could not authorize %s: %v", domain, err)
+		}
+		d.Printf("%s: authorized\n", domain)
+	}
+
+	certPEM, keyPEM, err := acmeCreateCert(ctx, client, order.URI, domainList)
+	if err != nil {
+		return fmt.Errorf("could not issue a certificate for %s: %v", strings.Join(domainList, ", "), err)
+	}
+
+	summary, err := inspectCert(certPEM, keyPEM, now, false)
+	if err != nil {
+		return fmt.Errorf("the issued certificate is not valid: %v", err)
+	}
+	d.Println(summary)
+
+	d.Printf("Adding certificate %s... ", name)
+	_, err = certs.New(s.Client, string(certPEM), string(keyPEM), name)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	d.Println("done")
+
+	failed := 0
+	for _, domain := range domainList {
+		if err = certs.Attach(s.Client, name, domain); d.checkAPICompatibility(s.Client, err) != nil {
+			d.Printf("%s: could not be attached: %v\n", domain, err)
+			failed++
+			continue
+		}
+		d.Printf("%s: attached\n", domain)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("certificate %s could not be attached to %d domain(s), add them with domains:add and run certs:attach",
+			name, failed)
+	}
+
+	return nil
+}
+
+// acmeClient returns a client for the ACME directory, using the account saved for it or
+// registering a new one.
+func (d *DeisCmd) acmeClient(ctx context.Context, directory, email string, agreeTOS bool) (*acme.Client, error) {
+	accounts := make(map[string]acmeAccount)
+	if err := loadState(d.ConfigFile, "acme-accounts", &accounts); err != nil {
+		return nil, err
+	}
+
+	if account, found := accounts[directory]; found {
+		block, _ := pem.Decode([]byte(account.Key))
+		if block == nil {
+			return nil, fmt.Errorf("the ACME account key for %s is not PEM encoded", directory)
+		}
+
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return &acme.Client{Key: key, AccountURL: account.URI, DirectoryURL: directory}, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{Key: key, DirectoryURL: directory}
+
+	var contact []string
+	if email != "" {
+		contact = []string{"mailto:" + email}
+	}
+
+	d.Printf("Registering an account with %s...\n", directory)
+	declined := ""
+	account, err := client.Register(ctx, &acme.Account{Contact: contact}, func(tos string) bool {
+		if agreeTOS {
+			d.Printf("Agreeing to the terms of service at %s\n", tos)
+			return true
+		}
+
+		confirm := ""
+		d.Printf("agree to the terms of service at %s? (y/N): ", tos)
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			declined = tos
+			return false
+		}
+		return true
+	})
+	if declined != "" {
+		return nil, fmt.Errorf("you must agree to the terms of service at %s to register an account, or pass --agree-tos", declined)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	accounts[directory] = acmeAccount{
+		Key: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+		URI: account.URI,
+	}
+
+	return client, saveState(d.ConfigFile, "acme-accounts", accounts)
+}
+
+// acmeAuthorize completes the authorization at authzURL with the given challenge type,
+// proving control of its domain to the ACME server. It returns the domain.
+func (d *DeisCmd) acmeAuthorize(ctx context.Context, client *acme.Client, authzURL, challenge string,
+	responder *http01Responder) (string, error) {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return authzURL, err
+	}
+	domain := authz.Identifier.Value
+	if authz.Status == acme.StatusValid {
+		return domain, nil
+	}
+
+	d.Printf("Authorizing %s with %s...\n", domain, challenge)
+
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == challenge {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return domain, fmt.Errorf("the ACME server offers no %s challenge", challenge)
+	}
+
+	switch challenge {
+	case "http-01":
+		response, err := client.HTTP01ChallengeResponse(chal.Token)
+		if err != nil {
+			return domain, err
+		}
+		responder.set(client.HTTP01ChallengePath(chal.Token), response)
+	case "dns-01":
+		record, err := client.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			return domain, err
+		}
+
+		d.Printf("Create a TXT record for _acme-challenge.%s with the value %s\n", domain, record)
+		d.Println("Waiting for the record to resolve...")
+
+		if err = waitForTXT(ctx, d.resolver(), "_acme-challenge."+domain, record); err != nil {
+			return domain, err
+		}
+	}
+
+	if _, err = client.Accept(ctx, chal); err != nil {
+		return domain, err
+	}
+
+	_, err = client.WaitAuthorization(ctx, authz.URI)
+	return domain, err
+}
+
+// waitForTXT polls DNS until name has a TXT record with the given value.
+func waitForTXT(ctx context.Context, resolver Resolver, name, value string) error {
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsTimeout)
+		records, _ := resolver.LookupTXT(lookupCtx, name)
+		cancel()
+
+		for _, record := range records {
+			if record == value {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the TXT record %s", name)
+		case <-time.After(acmePollInterval):
+		}
+	}
+}
+
+// acmeCreateCert waits for the order at orderURL to be ready, then finalizes it with a new
+// private key for domains. It returns the certificate chain and the key PEM encoded.
+func acmeCreateCert(ctx context.Context, client *acme.Client, orderURL string, domainList []string) ([]byte, []byte, error) {
+	order, err := client.WaitOrder(ctx, orderURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domainList[0]},
+		DNSNames: domainList,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain, err := client.CreateOrderCert(ctx, order, csr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// http01Responder serves the responses to http-01 challenges.
+type http01Responder struct {
+	sync.Mutex
+	responses map[string]string
+}
+
+func (r *http01Responder) set(path, response string) {
+	r.Lock()
+	defer r.Unlock()
+	r.responses[path] = response
+}
+
+func (r *http01Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	response, found := r.responses[req.URL.Path]
+	r.Unlock()
+
+	if !found {
+		http.NotFound(w, req)
+		return
+	}
+	fmt.Fprint(w, response)
+}
+
+// matchCertName returns the first certificate name which covers domain, or "" if none do.
+// A wildcard name covers exactly one label, so *.example.com covers www.example.com but
+// neither example.com nor a.www.example.com.
+func matchCertName(names []string, domain string) string {
+	domain = strings.ToLower(domain)
+	for _, name := range names {
+		pattern := strings.ToLower(name)
+		if pattern == domain {
+			return name
+		}
+
+		if strings.HasPrefix(pattern, "*.") {
+			if i := strings.Index(domain, "."); i > 0 && domain[i:] == pattern[1:] {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
 // CertDetach detaches a certificate from a domain
 func (d *DeisCmd) CertDetach(name, domain string) error {
 	s, err := settings.Load(d.ConfigFile)
@@ -2,17 +2,129 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/controller-sdk-go/api"
+	"github.com/deis/workflow-cli/pkg/acme"
 	"github.com/deis/workflow-cli/pkg/testutil"
 )
 
+func TestCertsCheck(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/certs/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 4,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"name": "test-example-com", "common_name": "test.example.com", "expires": "2016-06-01T00:00:00UTC"},
+				{"name": "test-deis-com", "common_name": "test.deis.com", "expires": "2016-08-01T00:00:00UTC"},
+				{"name": "test1", "common_name": "1.test.deis.com", "expires": "2016-06-11T12:00:00UTC"},
+				{"name": "test2", "common_name": "2.test.deis.com"}
+			]
+		}`)
+	})
+
+	now := time.Date(2016, time.June, 9, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	err = cmdr.CertsCheck(30*day, now)
+	assert.Equal(t, err, ExitCodeError{Code: 2}, "exit code")
+	assert.Equal(t, b.String(), `CRITICAL: 1 cert(s) expired, 1 expiring within 30d
+test-example-com expired 8d ago (1 Jun 2016)
+test1 expires in 2d (11 Jun 2016)
+`, "output")
+
+	b.Reset()
+	err = cmdr.CertsCheck(90*day, now.Add(-10*day))
+	assert.Equal(t, err, ExitCodeError{Code: 1}, "exit code")
+	assert.Equal(t, b.String(), `WARNING: 3 cert(s) expiring within 90d
+test-example-com expires in 2d (1 Jun 2016)
+test-deis-com expires in 63d (1 Aug 2016)
+test1 expires in 12d (11 Jun 2016)
+`, "output")
+
+	b.Reset()
+	err = cmdr.CertsCheck(day, now.Add(-10*day))
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "OK: no certs expiring within 1d\n", "output")
+
+	// a check which can't list the certs doesn't know their state.
+	cf, server, err = testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.Mux.HandleFunc("/v2/certs/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	b.Reset()
+	cmdr.ConfigFile = cf
+	err = cmdr.CertsCheck(day, now)
+	assert.Equal(t, err, ExitCodeError{Code: 3}, "exit code")
+	assert.True(t, strings.HasPrefix(b.String(), "UNKNOWN: "), "output")
+
+	b.Reset()
+	cmdr.ConfigFile = filepath.Join(filepath.Dir(cf), "missing.json")
+	err = cmdr.CertsCheck(day, now)
+	assert.Equal(t, err, ExitCodeError{Code: 3}, "exit code")
+	assert.True(t, strings.HasPrefix(b.String(), "UNKNOWN: "), "output")
+}
+
+func TestLongDuration(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	cases := map[time.Duration]string{
+		30 * time.Second: "less than a minute",
+		time.Minute:      "1 minute",
+		5 * time.Hour:    "5 hours",
+		day:              "1 day",
+		29 * day:         "29 days",
+		53 * day:         "1 month",
+		364 * day:        "12 months",
+		571 * day:        "1 year",
+		800 * day:        "2 years",
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, longDuration(input), expected, "duration")
+	}
+}
+
 func TestCertsList(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -71,12 +183,12 @@ func TestCertsList(t *testing.T) {
 	err = cmdr.CertsList(-1, time.Date(2016, time.June, 9, 0, 0, 0, 0, time.UTC))
 	assert.NoErr(t, err)
 
-	assert.Equal(t, b.String(), `        Name       |   Common Name    |    SubjectAltName    |         Expires          |   Fingerprint   |       Domains        |  Updated   |  Created    
-+------------------+------------------+----------------------+--------------------------+-----------------+----------------------+------------+------------+
-  test-example-com | test.example.com | test.com,example.com | 10 Nov 2014 (expired)    | 12:34[...]78:90 | test.com,example.com | 9 Jun 2016 | 9 Jun 2016  
-  test-deis-com    | test.deis.com    |                      | 1 Aug 2016 (in 2 months) | ab:12[...]12:ab |                      | 9 Jun 2016 | 9 Jun 2016  
-  test1            | 1.test.deis.com  |                      | 11 Jun 2016 (in 2 days)  |                 |                      | unknown    | unknown     
-  test2            | 2.test.deis.com  |                      | 1 Jan 2018 (in 2 years)  |                 |                      | unknown    | unknown     
+	assert.Equal(t, b.String(), `        Name       |   Common Name    |    SubjectAltName    |         Expires         |   Fingerprint   |       Domains        |  Updated   |  Created    
++------------------+------------------+----------------------+-------------------------+-----------------+----------------------+------------+------------+
+  test-example-com | test.example.com | test.com,example.com | 10 Nov 2014 (expired)   | 12:34[...]78:90 | test.com,example.com | 9 Jun 2016 | 9 Jun 2016  
+  test-deis-com    | test.deis.com    |                      | 1 Aug 2016 (in 1 month) | ab:12[...]12:ab |                      | 9 Jun 2016 | 9 Jun 2016  
+  test1            | 1.test.deis.com  |                      | 11 Jun 2016 (in 2 days) |                 |                      | unknown    | unknown     
+  test2            | 2.test.deis.com  |                      | 1 Jan 2018 (in 1 year)  |                 |                      | unknown    | unknown     
 `, "output")
 
 	cf, server, err = testutil.NewTestServerAndClient()
@@ -271,6 +383,542 @@ func TestCertsAttach(t *testing.T) {
 	assert.Equal(t, testutil.StripProgress(b.String()), "Attaching certificate test-example-com to domain deis.com... done\n", "output")
 }
 
+func TestCertsAttachAuto(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/certs/star-example-com", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"name": "star-example-com",
+			"common_name": "*.example.com",
+			"san": [
+				"*.example.com",
+				"example.com"
+			],
+			"domains": [
+				"example.com"
+			]
+		}`)
+	})
+
+	server.Mux.HandleFunc("/v2/apps/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 2,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"id": "foo", "owner": "test"},
+				{"id": "bar", "owner": "test"}
+			]
+		}`)
+	})
+
+	server.Mux.HandleFunc("/v2/apps/foo/domains/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 3,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"app": "foo", "domain": "www.example.com"},
+				{"app": "foo", "domain": "example.com"},
+				{"app": "foo", "domain": "foo"}
+			]
+		}`)
+	})
+
+	server.Mux.HandleFunc("/v2/apps/bar/domains/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 2,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"app": "bar", "domain": "a.b.example.com"},
+				{"app": "bar", "domain": "API.example.com"}
+			]
+		}`)
+	})
+
+	var attached []string
+	server.Mux.HandleFunc("/v2/certs/star-example-com/domain/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		var body api.CertAttachRequest
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		attached = append(attached, body.Domain)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err = cmdr.CertAttachAuto("star-example-com", true)
+	assert.NoErr(t, err)
+
+	assert.Equal(t, b.String(), `  App |     Domain      |  Matched By    
++-----+-----------------+---------------+
+  foo | www.example.com | *.example.com  
+  bar | API.example.com | *.example.com  
+www.example.com: attached
+API.example.com: attached
+
+Attached 2, failed 0.
+`, "output")
+	assert.Equal(t, attached, []string{"www.example.com", "API.example.com"}, "attached domains")
+}
+
+func TestCertRotate(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	now := time.Date(2016, time.June, 9, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2016, time.September, 1, 0, 0, 0, 0, time.UTC)
+	root, rootKey, _, _ := newTestCert(t, "Test Root CA", true, notAfter, nil, nil)
+	_, _, certPEM, keyPEM := newTestCert(t, "*.example.com", false, notAfter, root, rootKey)
+
+	certFile, err := ioutil.TempFile("", "deis-cli-unit-test-cert")
+	assert.NoErr(t, err)
+	defer os.Remove(certFile.Name())
+	assert.NoErr(t, ioutil.WriteFile(certFile.Name(), certPEM, 0600))
+
+	keyFile, err := ioutil.TempFile("", "deis-cli-unit-test-key")
+	assert.NoErr(t, err)
+	defer os.Remove(keyFile.Name())
+	assert.NoErr(t, ioutil.WriteFile(keyFile.Name(), keyPEM, 0600))
+
+	// requests records each change made to the certs, failAttach makes attaching that
+	// domain to the new cert fail, and failRestore makes attaching it to the old cert fail.
+	var requests []string
+	failAttach, failRestore := "", ""
+	server.Mux.HandleFunc("/v2/certs/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/certs/old":
+			fmt.Fprintf(w, `{"name": "old", "common_name": "*.example.com", "domains": ["a.example.com", "b.example.com"]}`)
+			return
+		case r.Method == "POST" && r.URL.Path == "/v2/certs/":
+			var body api.CertCreateRequest
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, "add "+body.Name)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"name": "%s", "common_name": "*.example.com", "san": ["*.example.com"]}`, body.Name)
+			return
+		case r.Method == "POST":
+			var body api.CertAttachRequest
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, "attach "+r.URL.Path+" "+body.Domain)
+			restore := strings.HasPrefix(r.URL.Path, "/v2/certs/old/")
+			if (!restore && body.Domain == failAttach) || (restore && body.Domain == failRestore) {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			requests = append(requests, "delete "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	err = cmdr.CertRotate("old", certFile.Name(), keyFile.Name(), "", now)
+	assert.NoErr(t, err)
+
+	assert.Equal(t, requests, []string{
+		"add old-20160609",
+		"delete /v2/certs/old/domain/a.example.com",
+		"attach /v2/certs/old-20160609/domain/ a.example.com",
+		"delete /v2/certs/old/domain/b.example.com",
+		"attach /v2/certs/old-20160609/domain/ b.example.com",
+		"delete /v2/certs/old",
+	}, "requests")
+	assert.True(t, strings.HasSuffix(b.String(), `Adding certificate old-20160609... done
+a.example.com: moved to old-20160609
+b.example.com: moved to old-20160609
+Removing certificate old... done
+`), "output")
+
+	b.Reset()
+	requests = nil
+	failAttach = "b.example.com"
+
+	err = cmdr.CertRotate("old", certFile.Name(), keyFile.Name(), "new", now)
+	assert.True(t, strings.HasPrefix(err.Error(), "Rotation of old failed and was rolled back: "), "error")
+
+	assert.Equal(t, requests, []string{
+		"add new",
+		"delete /v2/certs/old/domain/a.example.com",
+		"attach /v2/certs/new/domain/ a.example.com",
+		"delete /v2/certs/old/domain/b.example.com",
+		"attach /v2/certs/new/domain/ b.example.com",
+		"attach /v2/certs/old/domain/ b.example.com",
+		"delete /v2/certs/new/domain/a.example.com",
+		"attach /v2/certs/old/domain/ a.example.com",
+		"delete /v2/certs/new",
+	}, "requests")
+	assert.True(t, strings.Contains(b.String(), "a.example.com: moved to new\nb.example.com: could not be attached to new: "), "output")
+	assert.True(t, strings.HasSuffix(b.String(), "Rolling back...\na.example.com: moved back to old\n"), "output")
+
+	b.Reset()
+	requests = nil
+	failRestore = "b.example.com"
+
+	err = cmdr.CertRotate("old", certFile.Name(), keyFile.Name(), "new", now)
+	assert.True(t, strings.HasPrefix(err.Error(), "Rotation of old failed and could not be fully rolled back: "), "error")
+	assert.True(t, strings.Contains(b.String(), "b.example.com: could not be attached to old again: "), "output")
+
+	// a certificate which doesn't cover every domain is never added.
+	_, _, narrowPEM, narrowKeyPEM := newTestCert(t, "a.example.com", false, notAfter, root, rootKey)
+	assert.NoErr(t, ioutil.WriteFile(certFile.Name(), narrowPEM, 0600))
+	assert.NoErr(t, ioutil.WriteFile(keyFile.Name(), narrowKeyPEM, 0600))
+	requests = nil
+
+	err = cmdr.CertRotate("old", certFile.Name(), keyFile.Name(), "new", now)
+	assert.Err(t, fmt.Errorf("%s does not cover b.example.com, which old is attached to", certFile.Name()), err)
+	assert.Equal(t, len(requests), 0, "requests")
+}
+
+// fakeACME is an RFC 8555 ACME server which validates challenges and issues certificates
+// signed by a test CA. http-01 responses are fetched from http01, whatever the domain.
+type fakeACME struct {
+	t        *testing.T
+	url      string
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	http01   string
+	resolver fakeResolver
+
+	mu            sync.Mutex
+	registrations int
+	agreed        bool
+	thumbprint    string
+	valid         map[string]bool
+	orders        [][]string
+	certs         [][]byte
+}
+
+// payload returns the payload of a JWS request, without checking its signature. The
+// thumbprint of the account key is kept when the request carries it.
+func (f *fakeACME) payload(r *http.Request, v interface{}) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+	}
+	assert.NoErr(f.t, json.NewDecoder(r.Body).Decode(&jws))
+
+	protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	assert.NoErr(f.t, err)
+	var header struct {
+		JWK *struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"jwk"`
+	}
+	assert.NoErr(f.t, json.Unmarshal(protected, &header))
+	if header.JWK != nil {
+		digest := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`,
+			header.JWK.Crv, header.JWK.Kty, header.JWK.X, header.JWK.Y)))
+		f.thumbprint = base64.RawURLEncoding.EncodeToString(digest[:])
+	}
+
+	if jws.Payload == "" || v == nil {
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	assert.NoErr(f.t, err)
+	assert.NoErr(f.t, json.Unmarshal(payload, v))
+}
+
+func (f *fakeACME) authz(w http.ResponseWriter, domain string) {
+	status := "pending"
+	if f.valid[domain] {
+		status = "valid"
+	}
+	fmt.Fprintf(w, `{
+		"status": "%s",
+		"identifier": {"type": "dns", "value": "%s"},
+		"challenges": [
+			{"type": "http-01", "url": "%s/chal/http-01/%s", "token": "token-%s", "status": "pending"},
+			{"type": "dns-01", "url": "%s/chal/dns-01/%s", "token": "token-%s", "status": "pending"}
+		]
+	}`, status, domain, f.url, domain, domain, f.url, domain, domain)
+}
+
+func (f *fakeACME) order(w http.ResponseWriter, status int, id int) {
+	domains := f.orders[id]
+	state := "ready"
+	for _, domain := range domains {
+		if !f.valid[domain] {
+			state = "pending"
+		}
+	}
+	if f.certs[id] != nil {
+		state = "valid"
+	}
+
+	var identifiers, authzs []string
+	for _, domain := range domains {
+		identifiers = append(identifiers, fmt.Sprintf(`{"type": "dns", "value": "%s"}`, domain))
+		authzs = append(authzs, fmt.Sprintf(`"%s/authz/%s"`, f.url, domain))
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/order/%d", f.url, id))
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{
+		"status": "%s",
+		"identifiers": [%s],
+		"authorizations": [%s],
+		"finalize": "%s/finalize/%d",
+		"certificate": "%s/cert/%d"
+	}`, state, strings.Join(identifiers, ", "), strings.Join(authzs, ", "), f.url, id, f.url, id)
+}
+
+func (f *fakeACME) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Replay-Nonce", "nonce")
+	if r.Method == "HEAD" {
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	switch parts[1] {
+	case "directory":
+		fmt.Fprintf(w, `{
+			"newNonce": "%s/nonce",
+			"newAccount": "%s/new-account",
+			"newOrder": "%s/new-order",
+			"meta": {"termsOfService": "%s/terms"}
+		}`, f.url, f.url, f.url, f.url)
+	case "new-account":
+		var req struct {
+			TermsAgreed bool `json:"termsOfServiceAgreed"`
+		}
+		f.payload(r, &req)
+		w.Header().Set("Location", f.url+"/account/1")
+		f.registrations++
+		f.agreed = req.TermsAgreed
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"status": "valid"}`)
+	case "new-order":
+		var req struct {
+			Identifiers []struct {
+				Value string `json:"value"`
+			} `json:"identifiers"`
+		}
+		f.payload(r, &req)
+		var domains []string
+		for _, identifier := range req.Identifiers {
+			domains = append(domains, identifier.Value)
+		}
+		f.orders = append(f.orders, domains)
+		f.certs = append(f.certs, nil)
+		f.order(w, http.StatusCreated, len(f.orders)-1)
+	case "order":
+		f.payload(r, nil)
+		id, err := strconv.Atoi(parts[2])
+		assert.NoErr(f.t, err)
+		f.order(w, http.StatusOK, id)
+	case "authz":
+		f.payload(r, nil)
+		f.authz(w, parts[2])
+	case "chal":
+		f.payload(r, nil)
+		challenge, domain := parts[2], parts[3]
+		keyAuth := "token-" + domain + "." + f.thumbprint
+		if challenge == "http-01" {
+			res, err := http.Get("http://" + f.http01 + "/.well-known/acme-challenge/token-" + domain)
+			assert.NoErr(f.t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			assert.NoErr(f.t, err)
+			f.valid[domain] = string(body) == keyAuth
+		} else {
+			digest := sha256.Sum256([]byte(keyAuth))
+			txts := f.resolver.txts["_acme-challenge."+domain]
+			f.valid[domain] = len(txts) == 1 && txts[0] == base64.RawURLEncoding.EncodeToString(digest[:])
+		}
+
+		status := "invalid"
+		if f.valid[domain] {
+			status = "valid"
+		}
+		fmt.Fprintf(w, `{"type": "%s", "url": "%s%s", "token": "token-%s", "status": "%s"}`,
+			challenge, f.url, r.URL.Path, domain, status)
+	case "finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		f.payload(r, &req)
+		id, err := strconv.Atoi(parts[2])
+		assert.NoErr(f.t, err)
+		der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+		assert.NoErr(f.t, err)
+		csr, err := x509.ParseCertificateRequest(der)
+		assert.NoErr(f.t, err)
+
+		for _, domain := range csr.DNSNames {
+			if !f.valid[domain] {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintf(w, `{"type": "urn:ietf:params:acme:error:unauthorized", "detail": "%s is not authorized"}`, domain)
+				return
+			}
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		}
+		cert, err := x509.CreateCertificate(rand.Reader, template, f.ca, csr.PublicKey, f.caKey)
+		assert.NoErr(f.t, err)
+		f.certs[id] = cert
+		f.order(w, http.StatusOK, id)
+	case "cert":
+		f.payload(r, nil)
+		id, err := strconv.Atoi(parts[2])
+		assert.NoErr(f.t, err)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.certs[id]})
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.ca.Raw})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCertIssue(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	resolver := fakeResolver{txts: make(map[string][]string)}
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf, Resolver: resolver}
+
+	// pick a free port to serve http-01 responses on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoErr(t, err)
+	listen := listener.Addr().String()
+	listener.Close()
+
+	ca, caKey, _, _ := newTestCert(t, "Test Root CA", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	acmeServer := &fakeACME{t: t, ca: ca, caKey: caKey, http01: listen, resolver: resolver, valid: make(map[string]bool)}
+	ts := httptest.NewServer(acmeServer)
+	defer ts.Close()
+	acmeServer.url = ts.URL
+	directory := ts.URL + "/directory"
+
+	var added []api.Cert
+	var attached []string
+	server.Mux.HandleFunc("/v2/certs/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		if r.URL.Path == "/v2/certs/" {
+			var body api.CertCreateRequest
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+			_, err := inspectCert([]byte(body.Certificate), []byte(body.Key), time.Now(), false)
+			assert.NoErr(t, err)
+			added = append(added, api.Cert{Name: body.Name})
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"name": "%s"}`, body.Name)
+			return
+		}
+
+		var body api.CertAttachRequest
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		attached = append(attached, r.URL.Path+" "+body.Domain)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err = cmdr.CertIssue([]string{"www.example.com", "example.com"}, "", directory, "http-01", "admin@example.com",
+		listen, true, time.Minute, time.Now())
+	assert.NoErr(t, err)
+
+	assert.True(t, strings.HasPrefix(b.String(), "Registering an account with "+directory+`...
+Agreeing to the terms of service at `+ts.URL+`/terms
+Authorizing www.example.com with http-01...
+www.example.com: authorized
+Authorizing example.com with http-01...
+example.com: authorized
+Common Name: www.example.com
+SubjectAltName: www.example.com,example.com
+Issuer: Test Root CA
+`), "output")
+	assert.True(t, strings.HasSuffix(b.String(), `Chain: 2 certificate(s)
+Adding certificate www-example-com... done
+www.example.com: attached
+example.com: attached
+`), "output")
+	assert.Equal(t, added, []api.Cert{{Name: "www-example-com"}}, "added certs")
+	assert.Equal(t, attached, []string{
+		"/v2/certs/www-example-com/domain/ www.example.com",
+		"/v2/certs/www-example-com/domain/ example.com",
+	}, "attached domains")
+
+	// the saved account is used again, so the dns-01 record can be computed up front.
+	accounts := make(map[string]acmeAccount)
+	assert.NoErr(t, loadState(cf, "acme-accounts", &accounts))
+	block, _ := pem.Decode([]byte(accounts[directory].Key))
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	assert.NoErr(t, err)
+	record, err := (&acme.Client{Key: key}).DNS01ChallengeRecord("token-api.example.com")
+	assert.NoErr(t, err)
+	resolver.txts["_acme-challenge.api.example.com"] = []string{record}
+
+	b.Reset()
+	err = cmdr.CertIssue([]string{"api.example.com"}, "api", directory, "dns-01", "", "", false, time.Minute, time.Now())
+	assert.NoErr(t, err)
+
+	assert.True(t, strings.HasPrefix(b.String(), `Authorizing api.example.com with dns-01...
+Create a TXT record for _acme-challenge.api.example.com with the value `+record+`
+Waiting for the record to resolve...
+api.example.com: authorized
+`), "output")
+	assert.Equal(t, acmeServer.registrations, 1, "registrations")
+	assert.True(t, acmeServer.agreed, "agreed to the terms of service")
+	assert.Equal(t, added[1].Name, "api", "added cert")
+
+	err = cmdr.CertIssue([]string{"*.example.com"}, "", directory, "dns-01", "", "", false, time.Minute, time.Now())
+	assert.Equal(t, err.Error(), "ACME can't issue wildcard certificates such as *.example.com", "error")
+}
+
+func TestMatchCertName(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"*.example.com", "deis.com"}
+	cases := []struct {
+		domain   string
+		expected string
+	}{
+		{"www.example.com", "*.example.com"},
+		{"WWW.Example.com", "*.example.com"},
+		{"*.example.com", "*.example.com"},
+		{"example.com", ""},
+		{"a.www.example.com", ""},
+		{"deis.com", "deis.com"},
+		{"www.deis.com", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, matchCertName(names, c.domain), c.expected, c.domain)
+	}
+}
+
 func TestCertsDetach(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -292,6 +940,101 @@ func TestCertsDetach(t *testing.T) {
 	assert.Equal(t, testutil.StripProgress(b.String()), "Detaching certificate test-example-com from domain deis.com... done\n", "output")
 }
 
+// newTestCert creates a certificate for commonName valid until notAfter, signed by parent
+// or self-signed if parent is nil. A CA certificate has no DNS names, other certificates
+// are valid for commonName. It returns the certificate, its key and both PEM encoded.
+func newTestCert(t *testing.T, commonName string, isCA bool, notAfter time.Time, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErr(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if !isCA {
+		template.DNSNames = []string{commonName}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoErr(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoErr(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoErr(t, err)
+
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestInspectCert(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2016, time.June, 9, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2016, time.September, 1, 0, 0, 0, 0, time.UTC)
+	root, rootKey, rootPEM, _ := newTestCert(t, "Test Root CA", true, notAfter, nil, nil)
+	intermediate, intermediateKey, intermediatePEM, _ := newTestCert(t, "Test Intermediate CA", true, notAfter, root, rootKey)
+	_, _, leafPEM, leafKeyPEM := newTestCert(t, "www.example.com", false, notAfter, intermediate, intermediateKey)
+	_, _, expiredPEM, expiredKeyPEM := newTestCert(t, "old.example.com", false, now.Add(-time.Hour), intermediate, intermediateKey)
+	_, _, _, otherKeyPEM := newTestCert(t, "other.example.com", false, notAfter, intermediate, intermediateKey)
+
+	join := func(blocks ...[]byte) []byte {
+		return bytes.Join(blocks, nil)
+	}
+
+	summary, err := inspectCert(join(leafPEM, intermediatePEM), leafKeyPEM, now, false)
+	assert.NoErr(t, err)
+	assert.Equal(t, summary, `Common Name: www.example.com
+SubjectAltName: www.example.com
+Issuer: Test Intermediate CA
+Expires: 1 Sep 2016 (in 84d)
+Key: ECDSA P-256
+Chain: 2 certificate(s)`, "summary")
+
+	summary, err = inspectCert(leafPEM, leafKeyPEM, now, false)
+	assert.NoErr(t, err)
+	assert.True(t, strings.HasSuffix(summary, `Chain: 1 certificate(s)
+Warning: no intermediate certificates are included, clients without Test Intermediate CA may not trust the certificate`), "summary")
+
+	cases := []struct {
+		cert     []byte
+		key      []byte
+		expected string
+	}{
+		{[]byte("cert"), leafKeyPEM, "no PEM encoded certificate found"},
+		{leafPEM, otherKeyPEM, "tls: private key does not match public key"},
+		{join(leafPEM, rootPEM, intermediatePEM), leafKeyPEM, "www.example.com is not signed by Test Root CA, the chain must list each certificate before its issuer"},
+		{join(expiredPEM, intermediatePEM), expiredKeyPEM, "old.example.com expired on 8 Jun 2016"},
+	}
+
+	for _, c := range cases {
+		_, err = inspectCert(c.cert, c.key, now, false)
+		assert.Equal(t, err.Error(), c.expected, "error")
+	}
+
+	// forcing turns the problems into warnings, but a certificate is still needed.
+	summary, err = inspectCert(join(expiredPEM, intermediatePEM), otherKeyPEM, now, true)
+	assert.NoErr(t, err)
+	assert.Equal(t, summary, `Common Name: old.example.com
+SubjectAltName: old.example.com
+Issuer: Test Intermediate CA
+Expires: 8 Jun 2016 (expired)
+Key: ECDSA P-256
+Chain: 2 certificate(s)
+Warning: tls: private key does not match public key
+Warning: old.example.com expired on 8 Jun 2016`, "summary")
+
+	_, err = inspectCert([]byte("cert"), leafKeyPEM, now, true)
+	assert.Equal(t, err.Error(), "no PEM encoded certificate found", "error")
+}
+
 func TestCertsAdd(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -302,27 +1045,58 @@ func TestCertsAdd(t *testing.T) {
 	var b bytes.Buffer
 	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
 
+	now := time.Date(2016, time.June, 9, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2016, time.September, 1, 0, 0, 0, 0, time.UTC)
+	root, rootKey, _, _ := newTestCert(t, "Test Root CA", true, notAfter, nil, nil)
+	_, _, certPEM, keyPEM := newTestCert(t, "www.example.com", false, notAfter, root, rootKey)
+
 	server.Mux.HandleFunc("/v2/certs/", func(w http.ResponseWriter, r *http.Request) {
 		testutil.SetHeaders(w)
-		testutil.AssertBody(t, api.CertCreateRequest{Certificate: "cert", Key: "key", Name: "testcert"}, r)
+		testutil.AssertBody(t, api.CertCreateRequest{Certificate: string(certPEM), Key: string(keyPEM), Name: "testcert"}, r)
 		w.WriteHeader(http.StatusCreated)
 		fmt.Fprintf(w, "{}")
 	})
 
 	keyFile, err := ioutil.TempFile("", "deis-cli-unit-test-key")
 	assert.NoErr(t, err)
-	_, err = keyFile.Write([]byte("key"))
+	defer os.Remove(keyFile.Name())
+	_, err = keyFile.Write(keyPEM)
 	assert.NoErr(t, err)
 	keyFile.Close()
 
 	certFile, err := ioutil.TempFile("", "deis-cli-unit-test-cert")
 	assert.NoErr(t, err)
-	_, err = certFile.Write([]byte("cert"))
+	defer os.Remove(certFile.Name())
+	_, err = certFile.Write(certPEM)
 	assert.NoErr(t, err)
 	certFile.Close()
 
-	err = cmdr.CertAdd(certFile.Name(), keyFile.Name(), "testcert")
+	err = cmdr.CertAdd(certFile.Name(), keyFile.Name(), "testcert", false, now)
 	assert.NoErr(t, err)
 
-	assert.Equal(t, testutil.StripProgress(b.String()), "Adding SSL endpoint... done\n", "output")
+	assert.Equal(t, testutil.StripProgress(b.String()), `Common Name: www.example.com
+SubjectAltName: www.example.com
+Issuer: Test Root CA
+Expires: 1 Sep 2016 (in 84d)
+Key: ECDSA P-256
+Chain: 1 certificate(s)
+Warning: no intermediate certificates are included, clients without Test Root CA may not trust the certificate
+Adding SSL endpoint... done
+`, "output")
+
+	err = cmdr.CertAdd(keyFile.Name(), keyFile.Name(), "testcert", false, now)
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s and %s are not a valid certificate and key: no PEM encoded certificate found",
+		keyFile.Name(), keyFile.Name()), "error")
+
+	// a cert which isn't valid yet, such as one uploaded ahead of a DNS cutover, needs --force.
+	early := now.AddDate(-1, 0, 0)
+	err = cmdr.CertAdd(certFile.Name(), keyFile.Name(), "testcert", false, early)
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s and %s are not a valid certificate and key: www.example.com is not valid until 1 Jan 2016",
+		certFile.Name(), keyFile.Name()), "error")
+
+	b.Reset()
+	err = cmdr.CertAdd(certFile.Name(), keyFile.Name(), "testcert", true, early)
+	assert.NoErr(t, err)
+	assert.True(t, strings.Contains(b.String(), "Warning: www.example.com is not valid until 1 Jan 2016\n"), "output")
+	assert.True(t, strings.HasSuffix(testutil.StripProgress(b.String()), "Adding SSL endpoint... done\n"), "output")
 }
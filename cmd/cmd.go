@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"time"
 
 	"github.com/deis/controller-sdk-go/api"
@@ -11,13 +13,16 @@ import (
 // Commander is interface definition for running commands
 type Commander interface {
 	AppCreate(string, string, string, bool) error
-	AppsList(int) error
-	AppInfo(string) error
+	AppsList(int, string, string, time.Time, []string, string, bool) error
+	AppsStatus(int) error
+	AppInfo(string, time.Time) error
 	AppOpen(string) error
 	AppLogs(string, int) error
-	AppRun(string, string) error
+	AppRun(string, string, time.Duration) error
+	AppRunScript(string, string, []string, []string, time.Duration) error
 	AppDestroy(string, string) error
 	AppTransfer(string, string) error
+	AppCost(string, *float64, *float64) error
 	AutoscaleList(string) error
 	AutoscaleSet(string, string, int, int, int) error
 	AutoscaleUnset(string, string) error
@@ -31,10 +36,14 @@ type Commander interface {
 	BuildsList(string, int) error
 	BuildsCreate(string, string, string) error
 	CertsList(int, time.Time) error
-	CertAdd(string, string, string) error
+	CertAdd(string, string, string, bool, time.Time) error
 	CertRemove(string) error
 	CertInfo(string) error
+	CertsCheck(time.Duration, time.Time) error
 	CertAttach(string, string) error
+	CertAttachAuto(string, bool) error
+	CertRotate(string, string, string, string, time.Time) error
+	CertIssue([]string, string, string, string, string, string, bool, time.Duration, time.Time) error
 	CertDetach(string, string) error
 	ConfigList(string, bool) error
 	ConfigSet(string, []string) error
@@ -44,9 +53,15 @@ type Commander interface {
 	DomainsList(string, int) error
 	DomainsAdd(string, string) error
 	DomainsRemove(string, string) error
+	DomainsSync(string, string, bool) error
+	DomainsCheck(string) error
 	GitRemote(string, string, bool) error
 	GitRemove(string) error
 	HealthchecksList(string, string) error
+	HealthchecksExport(string, string) error
+	HealthchecksSetFile(string, string) error
+	HealthchecksCopy(string, string, []string) error
+	HealthchecksTest(string, *api.Healthcheck) error
 	HealthchecksSet(string, string, string, *api.Healthcheck) error
 	HealthchecksUnset(string, string, []string) error
 	KeysList(int) error
@@ -56,14 +71,23 @@ type Commander interface {
 	LimitsSet(string, []string, string) error
 	LimitsUnset(string, []string, string) error
 	MaintenanceInfo(string) error
-	MaintenanceEnable(string) error
+	MaintenanceEnable(string, time.Duration) error
 	MaintenanceDisable(string) error
+	MaintenanceStatus(string, time.Time) error
 	PermsList(string, bool, int) error
 	PermCreate(string, string, bool) error
 	PermDelete(string, string, bool) error
-	PsList(string, int) error
-	PsScale(string, []string) error
+	PsList(string, int, time.Time) error
+	PsInfo(string, string, time.Time) error
+	PsWatch(string, time.Duration, time.Duration, bool, func() time.Time) error
+	PsScale([]string, string, []string, bool, bool) error
+	PsRestore([]string, string) error
+	PsScheduleList(string) error
+	PsScheduleSet(string, string, []string) error
+	PsScheduleUnset(string, []string) error
+	PsApplyProfile(string, string) error
 	PsRestart(string, string) error
+	PsRollingRestart(string, string, int, time.Duration, time.Duration) error
 	RegistryList(string) error
 	RegistrySet(string, []string) error
 	RegistryUnset(string, []string) error
@@ -84,6 +108,7 @@ type Commander interface {
 	WhitelistAdd(string, string) error
 	WhitelistList(string) error
 	WhitelistRemove(string, string) error
+	WhitelistSync(string, string, bool) error
 	Println(...interface{}) (int, error)
 	Print(...interface{}) (int, error)
 	Printf(string, ...interface{}) (int, error)
@@ -100,6 +125,79 @@ type DeisCmd struct {
 	WOut       io.Writer
 	WErr       io.Writer
 	WIn        io.Reader
+	// Resolver looks up DNS records for domains:check and certs:issue. The system resolver
+	// is used if nil.
+	Resolver Resolver
+}
+
+// Resolver looks up DNS records.
+type Resolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// resolver returns the Resolver to look up DNS records with.
+func (d *DeisCmd) resolver() Resolver {
+	if d.Resolver != nil {
+		return d.Resolver
+	}
+
+	return netResolver{}
+}
+
+// netResolver is the system resolver, looking up DNS records with the net package.
+type netResolver struct{}
+
+func (netResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	records, err := lookupWithContext(ctx, func() ([]string, error) {
+		cname, err := net.LookupCNAME(host)
+		return []string{cname}, err
+	})
+	if len(records) == 0 {
+		return "", err
+	}
+	return records[0], err
+}
+
+func (netResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return lookupWithContext(ctx, func() ([]string, error) { return net.LookupHost(host) })
+}
+
+func (netResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return lookupWithContext(ctx, func() ([]string, error) { return net.LookupTXT(name) })
+}
+
+// lookupWithContext runs a lookup, giving up when ctx is done. The net package's lookups
+// can't be cancelled, so a lookup which is given up on finishes in the background.
+func lookupWithContext(ctx context.Context, lookup func() ([]string, error)) ([]string, error) {
+	type result struct {
+		records []string
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		records, err := lookup()
+		done <- result{records, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.records, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ExitCodeError is returned by commands which need the CLI to exit with a specific
+// status code, such as the exit code of a command run inside an app container.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
 }
 
 // Println prints a line to an output writer.
@@ -1,6 +1,19 @@
 package cmd
 
-import "github.com/deis/controller-sdk-go/domains"
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deis/controller-sdk-go/domains"
+	"github.com/deis/pkg/prettyprint"
+)
 
 // DomainsList lists domains registered with an app.
 func (d *DeisCmd) DomainsList(appID string, results int) error {
@@ -70,3 +83,257 @@ func (d *DeisCmd) DomainsRemove(appID, domain string) error {
 	d.Println("done")
 	return nil
 }
+
+// DomainsSync makes an app's domains match the domains listed in a file, one per line. Domains
+// missing from the app are added, and with prune, domains not in the file are removed.
+func (d *DeisCmd) DomainsSync(appID, file string, prune bool) error {
+	wanted, err := readDomainsFile(file)
+	if err != nil {
+		return err
+	}
+
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	current, count, err := domains.List(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	if count > len(current) {
+		if current, _, err = domains.List(s.Client, appID, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+	}
+
+	existing := make(map[string]bool, len(current))
+	for _, domain := range current {
+		existing[domain.Domain] = true
+	}
+
+	d.Printf("Syncing domains of %s with %s...\n", appID, file)
+
+	added, removed, unchanged, failed := 0, 0, 0, 0
+	listed := make(map[string]bool, len(wanted))
+	for _, domain := range wanted {
+		listed[domain] = true
+		if existing[domain] {
+			d.Printf("%s: already added\n", domain)
+			unchanged++
+			continue
+		}
+
+		if _, err = domains.New(s.Client, appID, domain); d.checkAPICompatibility(s.Client, err) != nil {
+			d.Printf("%s: could not be added: %v\n", domain, err)
+			failed++
+			continue
+		}
+		d.Printf("%s: added\n", domain)
+		added++
+	}
+
+	if prune {
+		for _, domain := range current {
+			// the app's own domain is managed by the controller.
+			if listed[domain.Domain] || domain.Domain == appID {
+				continue
+			}
+
+			if err = domains.Delete(s.Client, appID, domain.Domain); d.checkAPICompatibility(s.Client, err) != nil {
+				d.Printf("%s: could not be removed: %v\n", domain.Domain, err)
+				failed++
+				continue
+			}
+			d.Printf("%s: removed\n", domain.Domain)
+			removed++
+		}
+	}
+
+	d.Printf("\nAdded %d, removed %d, unchanged %d, failed %d.\n", added, removed, unchanged, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d domain(s) could not be synced", failed)
+	}
+
+	return nil
+}
+
+// readDomainsFile reads the domains listed in a file, one per line. Blank lines and lines
+// starting with # are skipped. Every invalid domain is reported at once.
+func readDomainsFile(file string) ([]string, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var domainList, problems []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for line := 1; scanner.Scan(); line++ {
+		domain := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if domain == "" || strings.HasPrefix(domain, "#") || seen[domain] {
+			continue
+		}
+
+		if err := validateDomain(domain); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		seen[domain] = true
+		domainList = append(domainList, domain)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s has invalid domains:\n%s", file, strings.Join(problems, "\n"))
+	}
+
+	return domainList, nil
+}
+
+var domainLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validateDomain checks that a domain is a valid hostname. A wildcard is only allowed as
+// the whole leftmost label, as in *.example.com.
+func validateDomain(domain string) error {
+	if len(domain) > 253 {
+		return fmt.Errorf("%s is longer than 253 characters", domain)
+	}
+
+	labels := strings.Split(domain, ".")
+	for i, label := range labels {
+		if label == "*" && i == 0 && len(labels) > 2 {
+			continue
+		}
+
+		if !domainLabelRegex.MatchString(label) {
+			return fmt.Errorf("%s is not a valid hostname", domain)
+		}
+	}
+
+	return nil
+}
+
+// dnsTimeout bounds each DNS lookup made by domains:check.
+const dnsTimeout = 5 * time.Second
+
+// DomainsCheck checks that each domain of an app resolves to the router, which serves the
+// controller's hostname. Domains pointing elsewhere are reported as misconfigured, and
+// domains that don't resolve at all as dangling.
+func (d *DeisCmd) DomainsCheck(appID string) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	domainList, count, err := domains.List(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	if count > len(domainList) {
+		if domainList, _, err = domains.List(s.Client, appID, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+	}
+
+	resolver := d.resolver()
+
+	routerHost := s.Client.ControllerURL.Host
+	if host, _, err := net.SplitHostPort(routerHost); err == nil {
+		routerHost = host
+	}
+	routerHost = strings.Trim(routerHost, "[]")
+
+	routerAddrs, err := lookupHost(resolver, routerHost)
+	if err != nil {
+		return fmt.Errorf("could not resolve the router at %s: %v", routerHost, err)
+	}
+	routerDomain := routerDomainOf(routerHost)
+
+	d.Printf("=== %s Domains DNS\n", appID)
+
+	results := make(map[string]string, len(domainList))
+	problems := 0
+	for _, domain := range domainList {
+		status, ok := checkDomain(resolver, expandURL(routerHost, domain.Domain), routerDomain, routerAddrs)
+		if !ok {
+			problems++
+		}
+		results[domain.Domain] = status
+	}
+
+	d.Print(prettyprint.PrettyTabs(results, 5))
+
+	if problems > 0 {
+		return fmt.Errorf("%d domain(s) do not point at the router %s (%s)", problems, routerHost,
+			strings.Join(routerAddrs, ", "))
+	}
+
+	return nil
+}
+
+// routerDomainOf returns the router's domain, given the controller's hostname. The
+// controller is served by the router as deis.<router domain>, and each app as
+// <app>.<router domain>. If the controller is reached by address, there is no router domain
+// and domains can only be checked by address.
+func routerDomainOf(controllerHost string) string {
+	if net.ParseIP(controllerHost) != nil {
+		return ""
+	}
+
+	parts := strings.SplitN(controllerHost, ".", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// checkDomain describes where a domain points, and whether that is the router. Wildcard
+// domains are checked through an arbitrary name they cover.
+func checkDomain(resolver Resolver, domain, routerDomain string, routerAddrs []string) (string, bool) {
+	host := domain
+	if strings.HasPrefix(host, "*.") {
+		host = "deis-dns-check" + host[1:]
+	}
+
+	addrs, err := lookupHost(resolver, host)
+	if err != nil || len(addrs) == 0 {
+		return "Dangling, does not resolve", false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+	cname, err := resolver.LookupCNAME(ctx, host)
+	cname = strings.TrimSuffix(cname, ".")
+	if err == nil && cname != "" && cname != host {
+		if routerDomain != "" && (cname == routerDomain || strings.HasSuffix(cname, "."+routerDomain)) {
+			return "OK, CNAME " + cname, true
+		}
+	} else {
+		cname = ""
+	}
+
+	for _, addr := range addrs {
+		for _, routerAddr := range routerAddrs {
+			if addr == routerAddr {
+				return "OK, A " + strings.Join(addrs, ", "), true
+			}
+		}
+	}
+
+	if cname != "" {
+		return "Misconfigured, CNAME " + cname + " is not the router", false
+	}
+	return "Misconfigured, A " + strings.Join(addrs, ", ") + " is not the router", false
+}
+
+func lookupHost(resolver Resolver, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+	return resolver.LookupHost(ctx, host)
+}
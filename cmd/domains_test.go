@@ -2,8 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/arschles/assert"
@@ -135,3 +142,250 @@ func TestDomainsDelete(t *testing.T) {
 
 	assert.Equal(t, testutil.StripProgress(b.String()), "Removing example.example.com from foo... done\n", "output")
 }
+
+func TestDomainsSync(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	dir, err := ioutil.TempDir("", "domains")
+	assert.NoErr(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "domains.txt")
+	err = ioutil.WriteFile(file, []byte(`# marketing site
+example.com
+WWW.example.com
+
+*.example.com
+example.com
+`), 0600)
+	assert.NoErr(t, err)
+
+	var added []string
+	server.Mux.HandleFunc("/v2/apps/foo/domains/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		if r.Method == "POST" {
+			var req api.DomainCreateRequest
+			assert.NoErr(t, json.NewDecoder(r.Body).Decode(&req))
+			added = append(added, req.Domain)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("{}"))
+			return
+		}
+
+		fmt.Fprintf(w, `{
+    "count": 3,
+    "next": null,
+    "previous": null,
+    "results": [
+        {"app": "foo", "domain": "example.com", "owner": "test"},
+        {"app": "foo", "domain": "old.example.com", "owner": "test"},
+        {"app": "foo", "domain": "foo", "owner": "test"}
+    ]
+}`)
+	})
+	var removed []string
+	server.Mux.HandleFunc("/v2/apps/foo/domains/old.example.com", func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, r.URL.Path)
+		testutil.SetHeaders(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err = cmdr.DomainsSync("foo", file, false)
+	assert.NoErr(t, err)
+	assert.Equal(t, added, []string{"www.example.com", "*.example.com"}, "added")
+	assert.Equal(t, len(removed), 0, "removed")
+	assert.Equal(t, b.String(), fmt.Sprintf(`Syncing domains of foo with %s...
+example.com: already added
+www.example.com: added
+*.example.com: added
+
+Added 2, removed 0, unchanged 1, failed 0.
+`, file), "output")
+
+	b.Reset()
+	added = nil
+	err = cmdr.DomainsSync("foo", file, true)
+	assert.NoErr(t, err)
+	assert.Equal(t, removed, []string{"/v2/apps/foo/domains/old.example.com"}, "removed")
+	assert.Equal(t, b.String(), fmt.Sprintf(`Syncing domains of foo with %s...
+example.com: already added
+www.example.com: added
+*.example.com: added
+old.example.com: removed
+
+Added 2, removed 1, unchanged 1, failed 0.
+`, file), "output")
+
+	err = ioutil.WriteFile(file, []byte("example.com\nexample_site.com\nwww.*.example.com\n"), 0600)
+	assert.NoErr(t, err)
+	err = cmdr.DomainsSync("foo", file, false)
+	assert.Equal(t, err.Error(), file+` has invalid domains:
+line 2: example_site.com is not a valid hostname
+line 3: www.*.example.com is not a valid hostname`, "error")
+}
+
+func TestValidateDomain(t *testing.T) {
+	t.Parallel()
+
+	for _, domain := range []string{"example.com", "www.example.com", "*.example.com", "foo", "a-b.example.co.uk"} {
+		assert.NoErr(t, validateDomain(domain))
+	}
+
+	for _, domain := range []string{"-example.com", "example..com", "*.com", "*", "ex ample.com", strings.Repeat("a", 64) + ".com"} {
+		assert.Equal(t, validateDomain(domain).Error(), domain+" is not a valid hostname", "error")
+	}
+}
+
+// fakeResolver resolves hosts from fixed CNAME, address and TXT records.
+type fakeResolver struct {
+	cnames map[string]string
+	addrs  map[string][]string
+	txts   map[string][]string
+}
+
+func (r fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, found := r.cnames[host]; found {
+		return cname + ".", nil
+	}
+	return host + ".", nil
+}
+
+func (r fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if cname, found := r.cnames[host]; found {
+		host = cname
+	}
+	if addrs, found := r.addrs[host]; found {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host}
+}
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txts, found := r.txts[name]; found {
+		return txts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name}
+}
+
+func TestDomainsCheck(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	resolver := fakeResolver{
+		cnames: map[string]string{"www.example.com": "lb.example.net"},
+		addrs: map[string][]string{
+			"127.0.0.1":       {"127.0.0.1"},
+			"example.com":     {"127.0.0.1"},
+			"lb.example.net":  {"127.0.0.1"},
+			"old.example.com": {"10.0.0.1"},
+		},
+	}
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf, Resolver: resolver}
+
+	server.Mux.HandleFunc("/v2/apps/foo/domains/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+    "count": 4,
+    "next": null,
+    "previous": null,
+    "results": [
+        {"app": "foo", "domain": "example.com", "owner": "test"},
+        {"app": "foo", "domain": "www.example.com", "owner": "test"},
+        {"app": "foo", "domain": "old.example.com", "owner": "test"},
+        {"app": "foo", "domain": "gone.example.com", "owner": "test"}
+    ]
+}`)
+	})
+
+	err = cmdr.DomainsCheck("foo")
+	assert.Equal(t, err.Error(), "2 domain(s) do not point at the router 127.0.0.1 (127.0.0.1)", "error")
+	assert.Equal(t, b.String(), `=== foo Domains DNS
+example.com          OK, A 127.0.0.1
+gone.example.com     Dangling, does not resolve
+old.example.com      Misconfigured, A 10.0.0.1 is not the router
+www.example.com      OK, A 127.0.0.1
+`, "output")
+}
+
+func TestCheckDomain(t *testing.T) {
+	t.Parallel()
+
+	resolver := fakeResolver{
+		cnames: map[string]string{
+			"www.example.com":            "foo.example.org",
+			"shop.example.com":           "shops.example.net",
+			"deis-dns-check.example.com": "foo.example.org",
+		},
+		addrs: map[string][]string{
+			"foo.example.org":   {"10.0.0.2"},
+			"shops.example.net": {"10.0.0.3"},
+		},
+	}
+	routerAddrs := []string{"10.0.0.1"}
+
+	cases := []struct {
+		domain   string
+		expected string
+		ok       bool
+	}{
+		{"www.example.com", "OK, CNAME foo.example.org", true},
+		{"*.example.com", "OK, CNAME foo.example.org", true},
+		{"shop.example.com", "Misconfigured, CNAME shops.example.net is not the router", false},
+		{"gone.example.com", "Dangling, does not resolve", false},
+	}
+
+	for _, c := range cases {
+		status, ok := checkDomain(resolver, c.domain, routerDomainOf("deis.example.org"), routerAddrs)
+		assert.Equal(t, status, c.expected, "status")
+		assert.Equal(t, ok, c.ok, "ok")
+	}
+}
+
+func TestRouterDomainOf(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"deis.example.org":        "example.org",
+		"deis.apps.example.org":   "apps.example.org",
+		"localhost":               "",
+		"127.0.0.1":               "",
+		"2001:db8::1":             "",
+		"deis.10.0.0.1.nip.io":    "10.0.0.1.nip.io",
+		"deis.local3.deisapp.com": "local3.deisapp.com",
+	}
+
+	for host, expected := range cases {
+		assert.Equal(t, routerDomainOf(host), expected, "router domain of "+host)
+	}
+}
+
+func TestLookupWithContext(t *testing.T) {
+	t.Parallel()
+
+	records, err := lookupWithContext(context.Background(), func() ([]string, error) {
+		return []string{"10.0.0.1"}, nil
+	})
+	assert.NoErr(t, err)
+	assert.Equal(t, records, []string{"10.0.0.1"}, "records")
+
+	// a lookup which doesn't finish in time is given up on.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	unblock := make(chan struct{})
+	defer close(unblock)
+	_, err = lookupWithContext(ctx, func() ([]string, error) {
+		<-unblock
+		return nil, nil
+	})
+	assert.Equal(t, err, context.Canceled, "error")
+}
@@ -1,25 +1,93 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/deis/controller-sdk-go/api"
 	"github.com/deis/controller-sdk-go/config"
+	"github.com/olekukonko/tablewriter"
+	yaml "gopkg.in/yaml.v2"
 )
 
+// healthcheckProbes are the probes a process type can have, in the order they are shown.
+var healthcheckProbes = []struct{ key, name string }{
+	{"livenessProbe", "Liveness"},
+	{"readinessProbe", "Readiness"},
+}
+
 func (d *DeisCmd) printHealthCheck(healthcheck api.Healthchecks) {
-	d.Println("--- Liveness")
-	if livenessProbe, found := healthcheck["livenessProbe"]; found {
-		d.Println(livenessProbe)
-	} else {
-		d.Println("No liveness probe configured.")
+	table := tablewriter.NewWriter(d.WOut)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderLine(true)
+	table.SetHeader([]string{"Probe", "Type", "Path/Command", "Port", "Headers", "Initial Delay", "Timeout",
+		"Period", "Success", "Failure"})
+
+	for _, p := range healthcheckProbes {
+		probe, found := healthcheck[p.key]
+		if !found || probe == nil {
+			table.Append([]string{p.name, "Not configured", "", "", "", "", "", "", "", ""})
+			continue
+		}
+
+		probeType, target, port, headers := "Unknown", "", "", ""
+		switch {
+		case probe.HTTPGet != nil:
+			probeType, target, port = "httpGet", probe.HTTPGet.Path, strconv.Itoa(probe.HTTPGet.Port)
+			var pairs []string
+			for _, header := range probe.HTTPGet.HTTPHeaders {
+				pairs = append(pairs, header.String())
+			}
+			headers = strings.Join(pairs, ",")
+		case probe.TCPSocket != nil:
+			probeType, port = "tcpSocket", strconv.Itoa(probe.TCPSocket.Port)
+		case probe.Exec != nil:
+			probeType, target = "exec", strings.Join(probe.Exec.Command, " ")
+		}
+
+		table.Append([]string{
+			p.name,
+			probeType,
+			target,
+			port,
+			headers,
+			fmt.Sprintf("%ds", probe.InitialDelaySeconds),
+			fmt.Sprintf("%ds", probe.TimeoutSeconds),
+			fmt.Sprintf("%ds", probe.PeriodSeconds),
+			strconv.Itoa(probe.SuccessThreshold),
+			strconv.Itoa(probe.FailureThreshold),
+		})
 	}
 
-	d.Println("\n--- Readiness")
-	if readinessProbe, found := healthcheck["readinessProbe"]; found {
-		d.Println(readinessProbe)
-	} else {
-		d.Println("No readiness probe configured.")
+	table.Render()
+}
+
+// probeSummary describes a healthcheck probe on a single line.
+func probeSummary(probe *api.Healthcheck) string {
+	switch {
+	case probe == nil:
+		return "Not configured"
+	case probe.HTTPGet != nil:
+		return fmt.Sprintf("httpGet %s on port %d", probe.HTTPGet.Path, probe.HTTPGet.Port)
+	case probe.TCPSocket != nil:
+		return fmt.Sprintf("tcpSocket on port %d", probe.TCPSocket.Port)
+	case probe.Exec != nil:
+		return fmt.Sprintf("exec %s", strings.Join(probe.Exec.Command, " "))
+	default:
+		return "Unknown probe type"
 	}
 }
 
@@ -94,6 +162,157 @@ func (d *DeisCmd) HealthchecksSet(appID, healthcheckType, procType string, probe
 	return d.HealthchecksList(appID, procType)
 }
 
+// HealthchecksCopy copies the healthchecks of one process type to other process types,
+// replacing whatever probes they had.
+func (d *DeisCmd) HealthchecksCopy(appID, from string, to []string) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	source, found := cfg.Healthcheck[from]
+	if !found || source == nil || len(*source) == 0 {
+		return fmt.Errorf("No healthchecks configured for process type %s", from)
+	}
+
+	configObj := api.Config{Healthcheck: make(map[string]*api.Healthchecks)}
+	for _, procType := range to {
+		healthcheckMap := make(api.Healthchecks)
+		for _, p := range healthcheckProbes {
+			// probes the source doesn't have are unset so the copy is exact.
+			healthcheckMap[p.key] = (*source)[p.key]
+		}
+		configObj.Healthcheck[procType] = &healthcheckMap
+	}
+
+	d.Printf("Copying healthchecks from %s to %s... ", from, strings.Join(to, ", "))
+
+	quit := progress(d.WOut)
+	_, err = config.Set(s.Client, appID, configObj)
+	quit <- true
+	<-quit
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	d.Print("done\n\n")
+
+	return d.HealthchecksList(appID, "")
+}
+
+// HealthchecksSetFile sets the healthchecks of every process type listed in a YAML file, in
+// the format printed by 'deis healthchecks:list --yaml'.
+func (d *DeisCmd) HealthchecksSetFile(appID, filename string) error {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	healthchecks, err := parseHealthchecksYAML(contents)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %v", filename, err)
+	}
+
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	d.Printf("Applying healthchecks from %s... ", filename)
+
+	quit := progress(d.WOut)
+	_, err = config.Set(s.Client, appID, api.Config{Healthcheck: healthchecks})
+	quit <- true
+	<-quit
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	d.Print("done\n\n")
+
+	return d.HealthchecksList(appID, "")
+}
+
+// parseHealthchecksYAML is the reverse of healthchecksToYAML. It also checks that each
+// probe is a known probe with exactly one probe type.
+func parseHealthchecksYAML(contents []byte) (map[string]*api.Healthchecks, error) {
+	var generic interface{}
+	if err := yaml.Unmarshal(contents, &generic); err != nil {
+		return nil, err
+	}
+
+	jsonContents, err := json.Marshal(yamlToJSON(generic))
+	if err != nil {
+		return nil, err
+	}
+
+	var healthchecks map[string]*api.Healthchecks
+	if err = json.Unmarshal(jsonContents, &healthchecks); err != nil {
+		return nil, err
+	}
+
+	if len(healthchecks) == 0 {
+		return nil, errors.New("no healthchecks found")
+	}
+
+	for procType, probes := range healthchecks {
+		if probes == nil {
+			continue
+		}
+
+		for key, probe := range *probes {
+			known := false
+			for _, p := range healthcheckProbes {
+				known = known || p.key == key
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown probe %s for process type %s, must be one of: livenessProbe, readinessProbe", key, procType)
+			}
+
+			if probe == nil {
+				continue
+			}
+
+			probeTypes := 0
+			for _, set := range []bool{probe.HTTPGet != nil, probe.Exec != nil, probe.TCPSocket != nil} {
+				if set {
+					probeTypes++
+				}
+			}
+			if probeTypes != 1 {
+				return nil, fmt.Errorf("%s for process type %s must have exactly one of httpGet, exec or tcpSocket", key, procType)
+			}
+		}
+	}
+
+	return healthchecks, nil
+}
+
+// yamlToJSON converts the map[interface{}]interface{} values produced by the YAML decoder
+// into map[string]interface{} values the JSON encoder understands.
+func yamlToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprintf("%v", key)] = yamlToJSON(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = yamlToJSON(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
 // HealthchecksUnset removes an app's healthchecks.
 func (d *DeisCmd) HealthchecksUnset(appID, procType string, healthchecks []string) error {
 	s, appID, err := load(d.ConfigFile, appID)
@@ -130,3 +349,147 @@ func (d *DeisCmd) HealthchecksUnset(appID, procType string, healthchecks []strin
 
 	return d.HealthchecksList(appID, procType)
 }
+
+// HealthchecksExport prints an app's healthchecks as YAML, keyed by process type.
+func (d *DeisCmd) HealthchecksExport(appID, procType string) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	config, err := config.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	healthchecks := config.Healthcheck
+	if procType != "" {
+		healthcheck, found := config.Healthcheck[procType]
+		if !found {
+			return fmt.Errorf("no healthchecks for process type %s", procType)
+		}
+		healthchecks = map[string]*api.Healthchecks{procType: healthcheck}
+	}
+
+	out, err := healthchecksToYAML(healthchecks)
+	if err != nil {
+		return err
+	}
+
+	d.Print(string(out))
+
+	return nil
+}
+
+// healthchecksToYAML marshals healthchecks to YAML. The API types only carry JSON tags, so
+// they are converted through JSON to keep the same field names as the controller uses.
+func healthchecksToYAML(healthchecks map[string]*api.Healthchecks) ([]byte, error) {
+	contents, err := json.Marshal(healthchecks)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic map[string]interface{}
+	if err = json.Unmarshal(contents, &generic); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(generic)
+}
+
+// HealthchecksTest runs a probe against a local host the way the scheduler would, until it
+// passes or fails enough times in a row to meet its thresholds.
+func (d *DeisCmd) HealthchecksTest(host string, probe *api.Healthcheck) error {
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		// Kubernetes treats an unset timeout as one second.
+		timeout = time.Second
+	}
+	successThreshold, failureThreshold := probe.SuccessThreshold, probe.FailureThreshold
+	if successThreshold < 1 {
+		successThreshold = 1
+	}
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	d.Printf("Testing %s on %s...\n", probeSummary(probe), host)
+	time.Sleep(time.Duration(probe.InitialDelaySeconds) * time.Second)
+
+	successes, failures := 0, 0
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now()
+		err := runProbe(host, probe, timeout)
+		latency := time.Since(startTime) / time.Millisecond * time.Millisecond
+
+		if err != nil {
+			successes, failures = 0, failures+1
+			d.Printf("Attempt %d: failed in %s: %v\n", attempt, latency, err)
+		} else {
+			successes, failures = successes+1, 0
+			d.Printf("Attempt %d: passed in %s\n", attempt, latency)
+		}
+
+		if successes >= successThreshold {
+			d.Printf("Healthcheck passed %d time(s) in a row.\n", successes)
+			return nil
+		}
+		if failures >= failureThreshold {
+			return fmt.Errorf("Healthcheck failed %d time(s) in a row", failures)
+		}
+
+		time.Sleep(time.Duration(probe.PeriodSeconds) * time.Second)
+	}
+}
+
+// runProbe performs a single check of a probe against host.
+func runProbe(host string, probe *api.Healthcheck, timeout time.Duration) error {
+	switch {
+	case probe.HTTPGet != nil:
+		u := url.URL{
+			Scheme: "http",
+			Host:   net.JoinHostPort(host, strconv.Itoa(probe.HTTPGet.Port)),
+			Path:   probe.HTTPGet.Path,
+		}
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return err
+		}
+		for _, header := range probe.HTTPGet.HTTPHeaders {
+			req.Header.Set(header.Key, header.Value)
+		}
+
+		client := http.Client{Timeout: timeout}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		// same as the kubelet, any 2xx or 3xx response is a pass.
+		if res.StatusCode < 200 || res.StatusCode >= 400 {
+			return fmt.Errorf("HTTP status %s", res.Status)
+		}
+		return nil
+	case probe.TCPSocket != nil:
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(probe.TCPSocket.Port)), timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case probe.Exec != nil:
+		if len(probe.Exec.Command) == 0 {
+			return errors.New("no command to run")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err := exec.CommandContext(ctx, probe.Exec.Command[0], probe.Exec.Command[1:]...).Run()
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		return err
+	default:
+		return errors.New("unknown probe type")
+	}
+}
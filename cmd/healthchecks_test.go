@@ -2,8 +2,16 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/arschles/assert"
@@ -23,12 +31,46 @@ func TestPrintHealthCheck(t *testing.T) {
 
 	testHealthCheck := api.Healthchecks{}
 	cmdr.printHealthCheck(testHealthCheck)
-	assert.Equal(t, b.String(), "--- Liveness\nNo liveness probe configured.\n\n--- Readiness\nNo readiness probe configured.\n", "healthcheck")
+	assert.Equal(t, b.String(), `    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | Not configured |              |      |         |               |         |        |         |          
+  Readiness | Not configured |              |      |         |               |         |        |         |          
+`, "healthcheck")
 	b.Reset()
-	testHealthCheck["livenessProbe"] = &api.Healthcheck{}
-	testHealthCheck["readinessProbe"] = &api.Healthcheck{}
+	testHealthCheck["livenessProbe"] = &api.Healthcheck{
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+		HTTPGet: &api.HTTPGetProbe{
+			Path:        "/healthz",
+			Port:        8080,
+			HTTPHeaders: []*api.KVPair{{Key: "X-Probe", Value: "deis"}},
+		},
+	}
+	testHealthCheck["readinessProbe"] = &api.Healthcheck{
+		TimeoutSeconds:   2,
+		PeriodSeconds:    5,
+		SuccessThreshold: 2,
+		FailureThreshold: 1,
+		Exec:             &api.ExecProbe{Command: []string{"cat", "/tmp/ready"}},
+	}
 	cmdr.printHealthCheck(testHealthCheck)
-	assert.Equal(t, b.String(), "--- Liveness\nInitial Delay (seconds): 0\nTimeout (seconds): 0\nPeriod (seconds): 0\nSuccess Threshold: 0\nFailure Threshold: 0\nExec Probe: N/A\nHTTP GET Probe: N/A\nTCP Socket Probe: N/A\n\n--- Readiness\nInitial Delay (seconds): 0\nTimeout (seconds): 0\nPeriod (seconds): 0\nSuccess Threshold: 0\nFailure Threshold: 0\nExec Probe: N/A\nHTTP GET Probe: N/A\nTCP Socket Probe: N/A\n", "healthcheck")
+	assert.Equal(t, b.String(), `    Probe   |  Type   |  Path/Command  | Port |   Headers    | Initial Delay | Timeout | Period | Success | Failure  
++-----------+---------+----------------+------+--------------+---------------+---------+--------+---------+---------+
+  Liveness  | httpGet | /healthz       | 8080 | X-Probe=deis | 5s            | 1s      | 10s    | 1       | 3        
+  Readiness | exec    | cat /tmp/ready |      |              | 0s            | 2s      | 5s     | 2       | 1        
+`, "healthcheck")
+	b.Reset()
+	testHealthCheck["livenessProbe"] = &api.Healthcheck{TCPSocket: &api.TCPSocketProbe{Port: 5000}}
+	delete(testHealthCheck, "readinessProbe")
+	cmdr.printHealthCheck(testHealthCheck)
+	assert.Equal(t, b.String(), `    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | tcpSocket      |              | 5000 |         | 0s            | 0s      | 0s     | 0       | 0        
+  Readiness | Not configured |              |      |         |               |         |        |         |          
+`, "healthcheck")
 }
 
 func TestHealthchecksList(t *testing.T) {
@@ -78,18 +120,113 @@ func TestHealthchecksList(t *testing.T) {
 	assert.Equal(t, b.String(), `=== foo Healthchecks
 
 web/cmd:
---- Liveness
-Initial Delay (seconds): 50
-Timeout (seconds): 50
-Period (seconds): 10
-Success Threshold: 1
-Failure Threshold: 3
-Exec Probe: N/A
-HTTP GET Probe: Path="/" Port=80 HTTPHeaders=[]
-TCP Socket Probe: N/A
-
---- Readiness
-No readiness probe configured.
+    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | httpGet        | /            | 80   |         | 50s           | 50s     | 10s    | 1       | 3        
+  Readiness | Not configured |              |      |         |               |         |        |         |          
+`, "output")
+}
+
+func TestHealthchecksExport(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/foo/config/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+  "uuid": "c039a380-6068-4511-b35a-535a73b86ef5",
+  "app": "foo",
+  "owner": "bar",
+  "values": {},
+  "memory": {},
+  "cpu": {},
+  "tags": {},
+  "registry": {},
+  "healthcheck": {
+    "web": {
+      "livenessProbe": {
+        "initialDelaySeconds": 50,
+        "timeoutSeconds": 50,
+        "periodSeconds": 10,
+        "failureThreshold": 3,
+        "httpGet": {
+          "port": 80,
+          "path": "/",
+          "httpHeaders": [{"key": "X-Probe", "value": "deis"}]
+        },
+        "successThreshold": 1
+      }
+    },
+    "worker": {
+      "readinessProbe": {
+        "initialDelaySeconds": 1,
+        "timeoutSeconds": 1,
+        "periodSeconds": 5,
+        "failureThreshold": 1,
+        "exec": {
+          "command": ["cat", "/tmp/ready"]
+        },
+        "successThreshold": 1
+      }
+    }
+  },
+  "created": "2016-09-12T22:20:14Z",
+  "updated": "2016-09-12T22:20:14Z"
+}`)
+	})
+
+	err = cmdr.HealthchecksExport("foo", "worker")
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `worker:
+  readinessProbe:
+    exec:
+      command:
+      - cat
+      - /tmp/ready
+    failureThreshold: 1
+    initialDelaySeconds: 1
+    periodSeconds: 5
+    successThreshold: 1
+    timeoutSeconds: 1
+`, "output")
+
+	b.Reset()
+	err = cmdr.HealthchecksExport("foo", "cmd")
+	assert.Err(t, errors.New("no healthchecks for process type cmd"), err)
+	assert.Equal(t, b.String(), "", "output")
+
+	err = cmdr.HealthchecksExport("foo", "")
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `web:
+  livenessProbe:
+    failureThreshold: 3
+    httpGet:
+      httpHeaders:
+      - key: X-Probe
+        value: deis
+      path: /
+      port: 80
+    initialDelaySeconds: 50
+    periodSeconds: 10
+    successThreshold: 1
+    timeoutSeconds: 50
+worker:
+  readinessProbe:
+    exec:
+      command:
+      - cat
+      - /tmp/ready
+    failureThreshold: 1
+    initialDelaySeconds: 1
+    periodSeconds: 5
+    successThreshold: 1
+    timeoutSeconds: 1
 `, "output")
 }
 
@@ -188,32 +325,16 @@ func TestHealthchecksListAllHealthChecks(t *testing.T) {
 	assert.Equal(t, b.String(), `=== foo Healthchecks
 
 web:
---- Liveness
-Initial Delay (seconds): 50
-Timeout (seconds): 50
-Period (seconds): 10
-Success Threshold: 1
-Failure Threshold: 3
-Exec Probe: N/A
-HTTP GET Probe: Path="/" Port=80 HTTPHeaders=[]
-TCP Socket Probe: N/A
-
---- Readiness
-No readiness probe configured.
+    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | httpGet        | /            | 80   |         | 50s           | 50s     | 10s    | 1       | 3        
+  Readiness | Not configured |              |      |         |               |         |        |         |          
 
 web/cmd:
---- Liveness
-Initial Delay (seconds): 50
-Timeout (seconds): 50
-Period (seconds): 10
-Success Threshold: 1
-Failure Threshold: 3
-Exec Probe: N/A
-HTTP GET Probe: Path="/" Port=80 HTTPHeaders=[]
-TCP Socket Probe: N/A
-
---- Readiness
-No readiness probe configured.
+    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | httpGet        | /            | 80   |         | 50s           | 50s     | 10s    | 1       | 3        
+  Readiness | Not configured |              |      |         |               |         |        |         |          
 `, "output")
 }
 
@@ -265,18 +386,10 @@ func TestHealthchecksSet(t *testing.T) {
 === foo Healthchecks
 
 web/cmd:
---- Liveness
-Initial Delay (seconds): 50
-Timeout (seconds): 50
-Period (seconds): 10
-Success Threshold: 1
-Failure Threshold: 3
-Exec Probe: N/A
-HTTP GET Probe: Path="/" Port=80 HTTPHeaders=[]
-TCP Socket Probe: N/A
-
---- Readiness
-No readiness probe configured.
+    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | httpGet        | /            | 80   |         | 50s           | 50s     | 10s    | 1       | 3        
+  Readiness | Not configured |              |      |         |               |         |        |         |          
 `, "output")
 }
 
@@ -314,10 +427,220 @@ func TestHealthchecksUnset(t *testing.T) {
 === foo Healthchecks
 
 web/cmd:
---- Liveness
-No liveness probe configured.
-
---- Readiness
-No readiness probe configured.
+    Probe   |      Type      | Path/Command | Port | Headers | Initial Delay | Timeout | Period | Success | Failure  
++-----------+----------------+--------------+------+---------+---------------+---------+--------+---------+---------+
+  Liveness  | Not configured |              |      |         |               |         |        |         |          
+  Readiness | Not configured |              |      |         |               |         |        |         |          
 `, "output")
 }
+
+func TestHealthchecksTest(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b}
+
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && r.Header.Get("X-Probe") == "deis" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer app.Close()
+	host, portStr, err := net.SplitHostPort(app.Listener.Addr().String())
+	assert.NoErr(t, err)
+	port, err := strconv.Atoi(portStr)
+	assert.NoErr(t, err)
+
+	probe := &api.Healthcheck{
+		TimeoutSeconds:   1,
+		SuccessThreshold: 2,
+		FailureThreshold: 1,
+		HTTPGet: &api.HTTPGetProbe{
+			Path:        "/healthz",
+			Port:        port,
+			HTTPHeaders: []*api.KVPair{{Key: "X-Probe", Value: "deis"}},
+		},
+	}
+
+	err = cmdr.HealthchecksTest(host, probe)
+	assert.NoErr(t, err)
+	lines := strings.Split(b.String(), "\n")
+	assert.Equal(t, lines[0], fmt.Sprintf("Testing httpGet /healthz on port %d on %s...", port, host), "output")
+	assert.True(t, strings.HasPrefix(lines[1], "Attempt 1: passed in "), "first attempt passes")
+	assert.True(t, strings.HasPrefix(lines[2], "Attempt 2: passed in "), "second attempt passes")
+	assert.Equal(t, lines[3], "Healthcheck passed 2 time(s) in a row.", "output")
+
+	b.Reset()
+	probe.HTTPGet.Path = "/"
+	err = cmdr.HealthchecksTest(host, probe)
+	assert.Equal(t, err.Error(), "Healthcheck failed 1 time(s) in a row", "error")
+	assert.True(t, strings.Contains(b.String(), "HTTP status 503 Service Unavailable"), "status is reported")
+
+	b.Reset()
+	probe = &api.Healthcheck{TCPSocket: &api.TCPSocketProbe{Port: port}}
+	err = cmdr.HealthchecksTest(host, probe)
+	assert.NoErr(t, err)
+
+	b.Reset()
+	probe = &api.Healthcheck{FailureThreshold: 2, Exec: &api.ExecProbe{Command: []string{"false"}}}
+	err = cmdr.HealthchecksTest(host, probe)
+	assert.Equal(t, err.Error(), "Healthcheck failed 2 time(s) in a row", "error")
+	assert.True(t, strings.Contains(b.String(), "Attempt 2: failed in "), "both attempts are reported")
+}
+
+func TestHealthchecksCopy(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	liveness := &api.Healthcheck{
+		InitialDelaySeconds: 50,
+		TimeoutSeconds:      50,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+		HTTPGet:             &api.HTTPGetProbe{Path: "/", Port: 80},
+	}
+	copied := api.Healthchecks{"livenessProbe": liveness, "readinessProbe": nil}
+
+	server.Mux.HandleFunc("/v2/apps/foo/config/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			testutil.AssertBody(t, api.Config{Healthcheck: map[string]*api.Healthchecks{
+				"worker": &copied,
+				"api":    &copied,
+			}}, r)
+		}
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+  "uuid": "c039a380-6068-4511-b35a-535a73b86ef5",
+  "app": "foo",
+  "owner": "bar",
+  "values": {},
+  "memory": {},
+  "cpu": {},
+  "tags": {},
+  "registry": {},
+  "healthcheck": {
+    "web": {
+      "livenessProbe": {
+        "initialDelaySeconds": 50,
+        "timeoutSeconds": 50,
+        "periodSeconds": 10,
+        "failureThreshold": 3,
+        "httpGet": {
+          "port": 80,
+          "path": "/"
+        },
+        "successThreshold": 1
+      }
+    }
+  },
+  "created": "2016-09-12T22:20:14Z",
+  "updated": "2016-09-12T22:20:14Z"
+}`)
+	})
+
+	err = cmdr.HealthchecksCopy("foo", "web", []string{"worker", "api"})
+	assert.NoErr(t, err)
+	assert.True(t, strings.HasPrefix(testutil.StripProgress(b.String()), `Copying healthchecks from web to worker, api... done
+
+=== foo Healthchecks
+`), "output")
+
+	err = cmdr.HealthchecksCopy("foo", "worker", []string{"api"})
+	assert.Equal(t, err.Error(), "No healthchecks configured for process type worker", "error")
+}
+
+func TestHealthchecksSetFile(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	dir, err := ioutil.TempDir("", "healthchecks")
+	assert.NoErr(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "healthchecks.yml")
+	err = ioutil.WriteFile(file, []byte(`web:
+  livenessProbe:
+    httpGet:
+      path: /healthz
+      port: 8080
+      httpHeaders:
+      - key: X-Probe
+        value: deis
+    initialDelaySeconds: 5
+    timeoutSeconds: 1
+    periodSeconds: 10
+    successThreshold: 1
+    failureThreshold: 3
+worker:
+  readinessProbe:
+    exec:
+      command: [cat, /tmp/ready]
+    periodSeconds: 5
+`), 0600)
+	assert.NoErr(t, err)
+
+	server.Mux.HandleFunc("/v2/apps/foo/config/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			testutil.AssertBody(t, api.Config{Healthcheck: map[string]*api.Healthchecks{
+				"web": {"livenessProbe": {
+					InitialDelaySeconds: 5,
+					TimeoutSeconds:      1,
+					PeriodSeconds:       10,
+					SuccessThreshold:    1,
+					FailureThreshold:    3,
+					HTTPGet: &api.HTTPGetProbe{
+						Path:        "/healthz",
+						Port:        8080,
+						HTTPHeaders: []*api.KVPair{{Key: "X-Probe", Value: "deis"}},
+					},
+				}},
+				"worker": {"readinessProbe": {
+					PeriodSeconds: 5,
+					Exec:          &api.ExecProbe{Command: []string{"cat", "/tmp/ready"}},
+				}},
+			}}, r)
+		}
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"app": "foo", "owner": "bar", "healthcheck": {}}`)
+	})
+
+	err = cmdr.HealthchecksSetFile("foo", file)
+	assert.NoErr(t, err)
+	assert.Equal(t, testutil.StripProgress(b.String()), fmt.Sprintf(`Applying healthchecks from %s... done
+
+=== foo Healthchecks
+No health checks configured.
+`, file), "output")
+}
+
+func TestParseHealthchecksYAML(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"", "no healthchecks found"},
+		{"web:\n  startupProbe:\n    tcpSocket:\n      port: 80\n", "unknown probe startupProbe for process type web, must be one of: livenessProbe, readinessProbe"},
+		{"web:\n  livenessProbe:\n    periodSeconds: 5\n", "livenessProbe for process type web must have exactly one of httpGet, exec or tcpSocket"},
+		{"web:\n  livenessProbe:\n    tcpSocket:\n      port: 80\n    exec:\n      command: [ls]\n", "livenessProbe for process type web must have exactly one of httpGet, exec or tcpSocket"},
+	}
+
+	for _, c := range cases {
+		_, err := parseHealthchecksYAML([]byte(c.input))
+		assert.Equal(t, err.Error(), c.expected, "error")
+	}
+}
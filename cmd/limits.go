@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/deis/pkg/prettyprint"
 
@@ -32,7 +34,7 @@ func (d *DeisCmd) LimitsList(appID string) error {
 		memoryMap := make(map[string]string)
 
 		for key, value := range config.Memory {
-			memoryMap[key] = fmt.Sprintf("%v", value)
+			memoryMap[key] = displayLimit(value, "memory")
 		}
 
 		d.Print(prettyprint.PrettyTabs(memoryMap, 5))
@@ -45,7 +47,7 @@ func (d *DeisCmd) LimitsList(appID string) error {
 		cpuMap := make(map[string]string)
 
 		for key, value := range config.CPU {
-			cpuMap[key] = value.(string)
+			cpuMap[key] = displayLimit(value, "cpu")
 		}
 
 		d.Print(prettyprint.PrettyTabs(cpuMap, 5))
@@ -62,7 +64,7 @@ func (d *DeisCmd) LimitsSet(appID string, limits []string, limitType string) err
 		return err
 	}
 
-	limitsMap, err := parseLimits(limits)
+	limitsMap, err := parseLimits(limits, limitType)
 	if err != nil {
 		return err
 	}
@@ -128,11 +130,20 @@ func (d *DeisCmd) LimitsUnset(appID string, limits []string, limitType string) e
 	return d.LimitsList(appID)
 }
 
-func parseLimits(limits []string) (map[string]interface{}, error) {
+// limitOrUnlimited returns the limit set for a process type, or "Unlimited" if there is none.
+func limitOrUnlimited(limits map[string]interface{}, procType string) string {
+	if limit, found := limits[procType]; found && limit != nil {
+		return fmt.Sprintf("%v", limit)
+	}
+
+	return "Unlimited"
+}
+
+func parseLimits(limits []string, limitType string) (map[string]interface{}, error) {
 	limitsMap := make(map[string]interface{})
 
 	for _, limit := range limits {
-		key, value, err := parseLimit(limit)
+		key, value, err := parseLimit(limit, limitType)
 
 		if err != nil {
 			return nil, err
@@ -144,8 +155,12 @@ func parseLimits(limits []string) (map[string]interface{}, error) {
 	return limitsMap, nil
 }
 
-func parseLimit(limit string) (string, string, error) {
-	regex := regexp.MustCompile("^([A-z]+)=([0-9]+[bkmgBKMG]{1,2}|[0-9.]{1,5}|[0-9.]{1,5}[m]{0,1})$")
+// parseLimit splits a type=limit string and validates the limit, which is either a single
+// quantity or a request/limit pair of quantities. Quantities the controller accepts as they
+// are, such as 300 or 512M, are sent unchanged; other valid quantities, such as 512Mi or
+// 1.5G, are converted into a form the controller accepts.
+func parseLimit(limit, limitType string) (string, string, error) {
+	regex := regexp.MustCompile("^([A-z]+)=([^=/]+)(?:/([^=/]+))?$")
 
 	if !regex.MatchString(limit) {
 		return "", "", fmt.Errorf(`%s doesn't fit format type=#unit or type=#
@@ -154,5 +169,127 @@ Examples: web=2G worker=500M web=300`, limit)
 
 	capture := regex.FindStringSubmatch(limit)
 
-	return capture[1], capture[2], nil
+	request, err := parseQuantity(capture[2], limitType)
+	if err != nil {
+		return "", "", err
+	}
+
+	if capture[3] == "" {
+		return capture[1], controllerQuantity(capture[2], request, limitType), nil
+	}
+
+	max, err := parseQuantity(capture[3], limitType)
+	if err != nil {
+		return "", "", err
+	}
+
+	if request > max {
+		return "", "", fmt.Errorf("the request %s of %s is greater than its limit %s", capture[2], capture[1], capture[3])
+	}
+
+	return capture[1], controllerQuantity(capture[2], request, limitType) + "/" +
+		controllerQuantity(capture[3], max, limitType), nil
+}
+
+// controllerQuantity returns raw if the controller accepts it, or the parsed quantity
+// formatted so that it does.
+func controllerQuantity(raw string, quantity int64, limitType string) string {
+	if controllerLimitRegex.MatchString(raw) {
+		return raw
+	}
+
+	return formatQuantity(quantity, limitType)
+}
+
+var (
+	// controllerLimitRegex matches the limits the controller accepts.
+	controllerLimitRegex = regexp.MustCompile("^([0-9]+[bkmgBKMG]{1,2}|[0-9.]{1,5}|[0-9.]{1,5}[m]{0,1})$")
+	memoryQuantityRegex  = regexp.MustCompile(`^([0-9]*\.?[0-9]+)(?:([bB])|([kKmMgG])(?:i|[bB])?)?$`)
+	cpuQuantityRegex     = regexp.MustCompile(`^([0-9]*\.?[0-9]+)(m?)$`)
+	memoryUnits          = map[string]float64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+)
+
+// parseQuantity parses a Kubernetes style quantity. Memory is returned in bytes, and
+// understands B, K, M and G with an optional i or B suffix. The controller treats all of
+// them as powers of 1024. CPU is returned in millicores, and is either a number of cores
+// such as 0.5 or millicores such as 500m.
+func parseQuantity(quantity, limitType string) (int64, error) {
+	if limitType == "cpu" {
+		capture := cpuQuantityRegex.FindStringSubmatch(quantity)
+		if capture == nil {
+			return 0, fmt.Errorf("%s is not a valid CPU quantity, ex: 500m, 0.5, 2", quantity)
+		}
+
+		value, err := strconv.ParseFloat(capture[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		if capture[2] == "" {
+			value *= 1000
+		}
+
+		if value < 1 || value != float64(int64(value)) {
+			return 0, fmt.Errorf("%s is not a whole number of millicores, the smallest CPU quantity is 1m", quantity)
+		}
+
+		return int64(value), nil
+	}
+
+	capture := memoryQuantityRegex.FindStringSubmatch(quantity)
+	if capture == nil {
+		return 0, fmt.Errorf("%s is not a valid memory quantity, ex: 512M, 1Gi, 2G", quantity)
+	}
+
+	value, err := strconv.ParseFloat(capture[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	value *= memoryUnits[strings.ToUpper(capture[3])]
+
+	if value < 1 || value != float64(int64(value)) {
+		return 0, fmt.Errorf("%s is not a whole number of bytes", quantity)
+	}
+
+	return int64(value), nil
+}
+
+// formatQuantity formats a quantity returned by parseQuantity in the largest unit that
+// keeps it a whole number, the way the controller expects it.
+func formatQuantity(quantity int64, limitType string) string {
+	if limitType == "cpu" {
+		if quantity%1000 == 0 {
+			return strconv.FormatInt(quantity/1000, 10)
+		}
+		return fmt.Sprintf("%dm", quantity)
+	}
+
+	for _, unit := range []string{"G", "M", "K"} {
+		if size := int64(memoryUnits[unit]); quantity%size == 0 {
+			return fmt.Sprintf("%d%s", quantity/size, unit)
+		}
+	}
+	return fmt.Sprintf("%dB", quantity)
+}
+
+// displayLimit shows a limit in a consistent unit, megabytes for memory and millicores for
+// CPU, so limits can be compared at a glance. Both halves of a request/limit pair are shown.
+// Limits that can't be parsed are shown as is.
+func displayLimit(limit interface{}, limitType string) string {
+	raw := fmt.Sprintf("%v", limit)
+
+	var parts []string
+	for _, quantity := range strings.Split(raw, "/") {
+		value, err := parseQuantity(quantity, limitType)
+		if err != nil {
+			return raw
+		}
+
+		if limitType == "cpu" {
+			parts = append(parts, fmt.Sprintf("%dm", value))
+		} else {
+			parts = append(parts, strconv.FormatFloat(float64(value)/memoryUnits["M"], 'f', -1, 64)+"M")
+		}
+	}
+
+	return strings.Join(parts, "/")
 }
@@ -13,6 +13,7 @@ import (
 
 type parseLimitCase struct {
 	Input         string
+	LimitType     string
 	Key           string
 	Value         string
 	ExpectedError bool
@@ -23,19 +24,37 @@ func TestParseLimit(t *testing.T) {
 	t.Parallel()
 
 	cases := []parseLimitCase{
-		{"web=2G", "web", "2G", false, ""},
-		{"=1", "", "", true, `=1 doesn't fit format type=#unit or type=#
+		{"web=2G", "memory", "web", "2G", false, ""},
+		{"web=1Gi", "memory", "web", "1G", false, ""},
+		{"web=512MB", "memory", "web", "512MB", false, ""},
+		{"web=300", "memory", "web", "300", false, ""},
+		{"web=1.5G", "memory", "web", "1536M", false, ""},
+		{"web=2048M", "memory", "web", "2048M", false, ""},
+		{"web=256M/512M", "memory", "web", "256M/512M", false, ""},
+		{"web=256Mi/1.5G", "memory", "web", "256M/1536M", false, ""},
+		{"web=500m", "cpu", "web", "500m", false, ""},
+		{"web=0.5", "cpu", "web", "0.5", false, ""},
+		{"web=0.125000", "cpu", "web", "125m", false, ""},
+		{"web=2", "cpu", "web", "2", false, ""},
+		{"web=250m/1", "cpu", "web", "250m/1", false, ""},
+		{"=1", "memory", "", "", true, `=1 doesn't fit format type=#unit or type=#
 Examples: web=2G worker=500M web=300`},
-		{"web=", "", "", true, `web= doesn't fit format type=#unit or type=#
+		{"web=", "memory", "", "", true, `web= doesn't fit format type=#unit or type=#
 Examples: web=2G worker=500M web=300`},
-		{"1=", "", "", true, `1= doesn't fit format type=#unit or type=#
+		{"1=", "memory", "", "", true, `1= doesn't fit format type=#unit or type=#
 Examples: web=2G worker=500M web=300`},
-		{"web=G", "", "", true, `web=G doesn't fit format type=#unit or type=#
+		{"web=G", "memory", "", "", true, "G is not a valid memory quantity, ex: 512M, 1Gi, 2G"},
+		{"web=1Ti", "memory", "", "", true, "1Ti is not a valid memory quantity, ex: 512M, 1Gi, 2G"},
+		{"web=1G", "cpu", "", "", true, "1G is not a valid CPU quantity, ex: 500m, 0.5, 2"},
+		{"web=0.0001", "cpu", "", "", true, "0.0001 is not a whole number of millicores, the smallest CPU quantity is 1m"},
+		{"web=1G/512M", "memory", "", "", true, "the request 1G of web is greater than its limit 512M"},
+		{"web=2/500m", "cpu", "", "", true, "the request 2 of web is greater than its limit 500m"},
+		{"web=256M/", "memory", "", "", true, `web=256M/ doesn't fit format type=#unit or type=#
 Examples: web=2G worker=500M web=300`},
 	}
 
 	for _, check := range cases {
-		key, value, err := parseLimit(check.Input)
+		key, value, err := parseLimit(check.Input, check.LimitType)
 		if check.ExpectedError {
 			assert.Equal(t, err.Error(), check.ExpectedMsg, "error")
 		} else {
@@ -57,13 +76,13 @@ func TestLimitTags(t *testing.T) {
 	t.Parallel()
 
 	cases := []parseLimitsCase{
-		{[]string{"web=1G", "worker=2"}, map[string]interface{}{"web": "1G", "worker": "2"}, false, ""},
+		{[]string{"web=1G", "worker=512Mi"}, map[string]interface{}{"web": "1G", "worker": "512M"}, false, ""},
 		{[]string{"foo=", "web=1G"}, nil, true, `foo= doesn't fit format type=#unit or type=#
 Examples: web=2G worker=500M web=300`},
 	}
 
 	for _, check := range cases {
-		actual, err := parseLimits(check.Input)
+		actual, err := parseLimits(check.Input, "memory")
 		if check.ExpectedError {
 			assert.Equal(t, err.Error(), check.ExpectedMsg, "error")
 		} else {
@@ -73,6 +92,27 @@ Examples: web=2G worker=500M web=300`},
 	}
 }
 
+func TestDisplayLimit(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		limit     interface{}
+		limitType string
+		expected  string
+	}{
+		{"2G", "memory", "2048M"},
+		{"512K", "memory", "0.5M"},
+		{"256M/1G", "memory", "256M/1024M"},
+		{"1", "cpu", "1000m"},
+		{"250m/0.5", "cpu", "250m/500m"},
+		{"lots", "cpu", "lots"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, displayLimit(c.limit, c.limitType), c.expected, "limit")
+	}
+}
+
 func TestLimitsList(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -110,11 +150,11 @@ func TestLimitsList(t *testing.T) {
 	assert.Equal(t, b.String(), `=== enterprise Limits
 
 --- Memory
-web     2G
+web     2048M
 
 --- CPU
-web        2
-worker     1
+web        2000m
+worker     1000m
 `, "output")
 
 	server.Mux.HandleFunc("/v2/apps/franklin/config/", func(w http.ResponseWriter, r *http.Request) {
@@ -202,7 +242,8 @@ web     100m
 		if r.Method == "POST" {
 			testutil.AssertBody(t, api.Config{
 				Memory: map[string]interface{}{
-					"web": "1G",
+					"web":    "1G",
+					"worker": "256M/512M",
 				},
 			}, r)
 		}
@@ -212,7 +253,8 @@ web     100m
 			"app": "franklin",
 			"values": {},
 			"memory": {
-				"web": "1G"
+				"web": "1G",
+				"worker": "256M/512M"
 			},
 			"cpu": {},
 			"tags": {},
@@ -224,7 +266,7 @@ web     100m
 	})
 	b.Reset()
 
-	err = cmdr.LimitsSet("franklin", []string{"web=1G"}, "memory")
+	err = cmdr.LimitsSet("franklin", []string{"web=1G", "worker=256Mi/512M"}, "memory")
 	assert.NoErr(t, err)
 
 	assert.Equal(t, testutil.StripProgress(b.String()), `Applying limits... done
@@ -232,7 +274,8 @@ web     100m
 === franklin Limits
 
 --- Memory
-web     1G
+web        1024M
+worker     256M/512M
 
 --- CPU
 Unlimited
@@ -325,7 +368,7 @@ web     100m
 === franklin Limits
 
 --- Memory
-web     1G
+web     1024M
 
 --- CPU
 Unlimited
@@ -1,10 +1,27 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/deis/controller-sdk-go/api"
 	"github.com/deis/controller-sdk-go/appsettings"
 )
 
+// maintenanceFile stores the maintenance windows turned on from this client, keyed by app.
+const maintenanceFile = "maintenance"
+
+// maintenanceWindow records when and by whom maintenance mode was turned on, and when it
+// is due to be turned off, if it was turned on for a fixed time.
+type maintenanceWindow struct {
+	Since time.Time  `json:"since"`
+	By    string     `json:"by"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
 // MaintenanceInfo tells the informations about app's maintenance status
 func (d *DeisCmd) MaintenanceInfo(appID string) error {
 	s, appID, err := load(d.ConfigFile, appID)
@@ -26,8 +43,10 @@ func (d *DeisCmd) MaintenanceInfo(appID string) error {
 	return nil
 }
 
-// MaintenanceEnable turns on the maintenance for the app.
-func (d *DeisCmd) MaintenanceEnable(appID string) error {
+// MaintenanceEnable turns on the maintenance for the app. If window isn't zero, the command
+// keeps running and turns maintenance off again once the window has passed, or when it is
+// interrupted, terminated or its terminal hangs up.
+func (d *DeisCmd) MaintenanceEnable(appID string, window time.Duration) error {
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
@@ -48,6 +67,36 @@ func (d *DeisCmd) MaintenanceEnable(appID string) error {
 	}
 
 	d.Println("done")
+
+	record := maintenanceWindow{Since: time.Now(), By: s.Username}
+	if window > 0 {
+		until := record.Since.Add(window)
+		record.Until = &until
+	}
+	if err = saveMaintenanceWindow(d.ConfigFile, appID, &record); err != nil {
+		return err
+	}
+
+	if window == 0 {
+		return nil
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(interrupt)
+
+	d.Printf("Maintenance mode will be turned off at %s. Press Ctrl-C to turn it off now.\n",
+		record.Until.Format(time.RFC3339))
+
+	select {
+	case <-time.After(window):
+	case <-interrupt:
+	}
+
+	if err = d.MaintenanceDisable(appID); err != nil {
+		return fmt.Errorf("maintenance mode is still ON for %s, turn it off with deis maintenance:off -a %s: %v",
+			appID, appID, err)
+	}
 	return nil
 }
 
@@ -73,5 +122,77 @@ func (d *DeisCmd) MaintenanceDisable(appID string) error {
 	}
 
 	d.Println("done")
+	return saveMaintenanceWindow(d.ConfigFile, appID, nil)
+}
+
+// MaintenanceStatus reports whether maintenance mode is on for the app, and for windows
+// turned on from this client, since when, by whom and until when. Those are only known to
+// this client, so they are labelled as such, and forgotten once the controller reports
+// maintenance mode off, whoever turned it off.
+func (d *DeisCmd) MaintenanceStatus(appID string, now time.Time) error {
+	s, appID, err := load(d.ConfigFile, appID)
+
+	if err != nil {
+		return err
+	}
+
+	appSettings, err := appsettings.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	d.Printf("=== %s Maintenance\n", appID)
+
+	if appSettings.Maintenance == nil || !*appSettings.Maintenance {
+		d.Println("Status: off")
+		return saveMaintenanceWindow(d.ConfigFile, appID, nil)
+	}
+	d.Println("Status: on")
+
+	windows := make(map[string]maintenanceWindow)
+	if err = loadState(d.ConfigFile, maintenanceFile, &windows); err != nil {
+		return err
+	}
+
+	record, found := windows[appID]
+	if !found {
+		d.Println("Since:  unknown, it was not turned on from this client")
+		return nil
+	}
+
+	d.Println("\n--- Recorded by this client")
+	d.Printf("Since:  %s (%s ago)\n", record.Since.Format(time.RFC3339), shortDuration(now.Sub(record.Since)))
+	d.Printf("By:     %s\n", record.By)
+
+	switch {
+	case record.Until == nil:
+		d.Printf("Until:  not scheduled, turn it off with deis maintenance:off -a %s\n", appID)
+	case record.Until.Before(now):
+		d.Printf("Until:  %s (overdue by %s, turn it off with deis maintenance:off -a %s)\n",
+			record.Until.Format(time.RFC3339), shortDuration(now.Sub(*record.Until)), appID)
+	default:
+		d.Printf("Until:  %s (in %s)\n", record.Until.Format(time.RFC3339), shortDuration(record.Until.Sub(now)))
+	}
+
 	return nil
 }
+
+// saveMaintenanceWindow records the maintenance window of an app, or forgets it if window
+// is nil.
+func saveMaintenanceWindow(cf, appID string, window *maintenanceWindow) error {
+	windows := make(map[string]maintenanceWindow)
+	if err := loadState(cf, maintenanceFile, &windows); err != nil {
+		return err
+	}
+
+	if window == nil {
+		if _, found := windows[appID]; !found {
+			return nil
+		}
+		delete(windows, appID)
+	} else {
+		windows[appID] = *window
+	}
+
+	return saveState(cf, maintenanceFile, windows)
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/controller-sdk-go/api"
@@ -92,9 +95,144 @@ func TestMaintenanceEnable(t *testing.T) {
 		fmt.Fprintf(w, `{}`)
 	})
 
-	err = cmdr.MaintenanceEnable("lothlorien")
+	err = cmdr.MaintenanceEnable("lothlorien", 0)
 	assert.NoErr(t, err)
 	assert.Equal(t, testutil.StripProgress(b.String()), "Enabling maintenance mode for lothlorien... done\n", "output")
+
+	windows := make(map[string]maintenanceWindow)
+	assert.NoErr(t, loadState(cf, maintenanceFile, &windows))
+	assert.Equal(t, windows["lothlorien"].By, "test", "by")
+	assert.True(t, windows["lothlorien"].Until == nil, "until")
+}
+
+func TestMaintenanceEnableFor(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	var requests []bool
+	server.Mux.HandleFunc("/v2/apps/edoras/settings/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		var body api.AppSettings
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, *body.Maintenance)
+		fmt.Fprintf(w, `{}`)
+	})
+
+	err = cmdr.MaintenanceEnable("edoras", 10*time.Millisecond)
+	assert.NoErr(t, err)
+	assert.Equal(t, requests, []bool{true, false}, "requests")
+	assert.True(t, strings.Contains(b.String(), "Maintenance mode will be turned off at "), "output")
+
+	// the window is forgotten once maintenance mode is turned off.
+	windows := make(map[string]maintenanceWindow)
+	assert.NoErr(t, loadState(cf, maintenanceFile, &windows))
+	assert.Equal(t, len(windows), 0, "windows")
+}
+
+func TestMaintenanceEnableForStillOn(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/rohan/settings/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		var body api.AppSettings
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		if !*body.Maintenance {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{}`)
+	})
+
+	err = cmdr.MaintenanceEnable("rohan", 10*time.Millisecond)
+	assert.True(t, err != nil, "error")
+	assert.True(t, strings.HasPrefix(err.Error(),
+		"maintenance mode is still ON for rohan, turn it off with deis maintenance:off -a rohan: "), "error")
+
+	// the window is kept, so maintenance:status reports it as overdue.
+	windows := make(map[string]maintenanceWindow)
+	assert.NoErr(t, loadState(cf, maintenanceFile, &windows))
+	assert.True(t, windows["rohan"].Until != nil, "until")
+}
+
+func TestMaintenanceStatus(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	for _, app := range []string{"isengard", "moria", "shire"} {
+		maintenance := app != "shire"
+		server.Mux.HandleFunc("/v2/apps/"+app+"/settings/", func(w http.ResponseWriter, r *http.Request) {
+			testutil.SetHeaders(w)
+			fmt.Fprintf(w, `{"maintenance": %t}`, maintenance)
+		})
+	}
+
+	since := time.Date(2016, time.June, 9, 12, 0, 0, 0, time.UTC)
+	until := since.Add(30 * time.Minute)
+	assert.NoErr(t, saveMaintenanceWindow(cf, "isengard", &maintenanceWindow{Since: since, By: "saruman", Until: &until}))
+	assert.NoErr(t, saveMaintenanceWindow(cf, "shire", &maintenanceWindow{Since: since, By: "bilbo"}))
+
+	err = cmdr.MaintenanceStatus("isengard", since.Add(10*time.Minute))
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== isengard Maintenance
+Status: on
+
+--- Recorded by this client
+Since:  2016-06-09T12:00:00Z (10m ago)
+By:     saruman
+Until:  2016-06-09T12:30:00Z (in 20m)
+`, "output")
+
+	b.Reset()
+	err = cmdr.MaintenanceStatus("isengard", since.Add(2*time.Hour))
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== isengard Maintenance
+Status: on
+
+--- Recorded by this client
+Since:  2016-06-09T12:00:00Z (2h ago)
+By:     saruman
+Until:  2016-06-09T12:30:00Z (overdue by 1h, turn it off with deis maintenance:off -a isengard)
+`, "output")
+
+	b.Reset()
+	err = cmdr.MaintenanceStatus("moria", since)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== moria Maintenance
+Status: on
+Since:  unknown, it was not turned on from this client
+`, "output")
+
+	b.Reset()
+	err = cmdr.MaintenanceStatus("shire", since)
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "=== shire Maintenance\nStatus: off\n", "output")
+
+	// maintenance was turned off elsewhere, so the record of this client is stale.
+	windows := make(map[string]maintenanceWindow)
+	assert.NoErr(t, loadState(cf, maintenanceFile, &windows))
+	_, found := windows["shire"]
+	assert.False(t, found, "shire record")
+	_, found = windows["isengard"]
+	assert.True(t, found, "isengard record")
 }
 
 func TestMaintenanceDisable(t *testing.T) {
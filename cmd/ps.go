@@ -1,20 +1,25 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/deis/controller-sdk-go"
 	"github.com/deis/controller-sdk-go/api"
+	"github.com/deis/controller-sdk-go/config"
 	"github.com/deis/controller-sdk-go/ps"
+	"github.com/deis/pkg/prettyprint"
+	"github.com/deis/workflow-cli/settings"
 )
 
-// PsList lists an app's processes.
-func (d *DeisCmd) PsList(appID string, results int) error {
+// PsList lists an app's processes along with how long ago they were started.
+func (d *DeisCmd) PsList(appID string, results int, now time.Time) error {
 	s, appID, err := load(d.ConfigFile, appID)
 	if err != nil {
 		return err
@@ -29,28 +34,259 @@ func (d *DeisCmd) PsList(appID string, results int) error {
 		return err
 	}
 
-	printProcesses(appID, processes, d.WOut)
+	printProcesses(appID, processes, now, d.WOut)
 
 	return nil
 }
 
-// PsScale scales an app's processes.
-func (d *DeisCmd) PsScale(appID string, targets []string) error {
+// listProcesses lists all of an app's processes, fetching more than limit if the app has
+// more processes than that.
+func listProcesses(c *deis.Client, appID string, limit int) (api.PodsList, error) {
+	processes, count, err := ps.List(c, appID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if count > len(processes) {
+		if processes, _, err = ps.List(c, appID, count); err != nil {
+			return nil, err
+		}
+	}
+
+	return processes, nil
+}
+
+// clearScreen moves the cursor to the top left of the terminal and clears it.
+const clearScreen = "\033[H\033[2J"
+
+// PsWatch redraws an app's processes every interval, listing the processes whose state
+// changed since the last refresh. With untilReady, it returns once every process is up and
+// fails if a process crashes or, unless timeout is zero, the processes are not up within
+// timeout.
+func (d *DeisCmd) PsWatch(appID string, interval, timeout time.Duration, untilReady bool,
+	now func() time.Time) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	deadline := now().Add(timeout)
+	states := make(map[string]string)
+
+	for {
+		processes, err := listProcesses(s.Client, appID, s.Limit)
+		if d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+
+		d.Print(clearScreen)
+		printProcesses(appID, processes, now(), d.WOut)
+
+		var transitions, crashed []string
+		ready := len(processes) > 0
+		for _, pod := range processes {
+			if previous, found := states[pod.Name]; found && previous != pod.State {
+				transitions = append(transitions, fmt.Sprintf("%s %s -> %s", pod.Name, previous, pod.State))
+			}
+			if pod.State != "up" {
+				ready = false
+			}
+			if pod.State == "crashed" || pod.State == "error" {
+				crashed = append(crashed, fmt.Sprintf("%s is %s", pod.Name, pod.State))
+			}
+		}
+
+		states = make(map[string]string)
+		for _, pod := range processes {
+			states[pod.Name] = pod.State
+		}
+
+		if len(transitions) > 0 {
+			sort.Strings(transitions)
+			d.Println("\n=== Changed")
+			for _, transition := range transitions {
+				d.Println(transition)
+			}
+		}
+
+		if untilReady {
+			if ready {
+				d.Println("\nAll processes are up.")
+				return nil
+			}
+
+			if len(crashed) > 0 {
+				sort.Strings(crashed)
+				return fmt.Errorf("Not all processes will come up: %s", strings.Join(crashed, ", "))
+			}
+
+			if timeout > 0 && now().After(deadline) {
+				return fmt.Errorf("Processes were not all up within %s", timeout)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// PsInfo prints detailed information about one of an app's processes.
+func (d *DeisCmd) PsInfo(appID, podName string, now time.Time) error {
 	s, appID, err := load(d.ConfigFile, appID)
 	if err != nil {
 		return err
 	}
 
-	targetMap, err := parsePsTargets(targets)
+	processes, err := listProcesses(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	var pod *api.Pods
+	for i := range processes {
+		if processes[i].Name == podName {
+			pod = &processes[i]
+			break
+		}
+	}
+
+	if pod == nil {
+		return fmt.Errorf("Could not find process %s in app %s", podName, appID)
+	}
+
+	config, err := config.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	started := "unknown"
+	if pod.Started.Time != nil {
+		started = fmt.Sprintf("%s (%s ago)", pod.Started.Format(time.RFC3339),
+			shortDuration(now.Sub(*pod.Started.Time)))
+	}
+
+	healthchecks := api.Healthchecks{}
+	if procHealthchecks, found := config.Healthcheck[pod.Type]; found {
+		healthchecks = *procHealthchecks
+	}
+
+	d.Printf("=== %s Process\n", pod.Name)
+	d.Println("type:      ", pod.Type)
+	d.Println("state:     ", pod.State)
+	d.Println("release:   ", pod.Release)
+	d.Println("started:   ", started)
+	d.Println("memory:    ", limitOrUnlimited(config.Memory, pod.Type))
+	d.Println("cpu:       ", limitOrUnlimited(config.CPU, pod.Type))
+	d.Println("liveness:  ", probeSummary(healthchecks["livenessProbe"]))
+	d.Println("readiness: ", probeSummary(healthchecks["readinessProbe"]))
+
+	return nil
+}
+
+// savedScaleFile stores the process counts saved by ps:scale --save, keyed by app.
+const savedScaleFile = "scale"
+
+// PsScale scales the processes of one or more apps, given by name or selected by tag. With
+// save, the current process counts are saved first so they can be restored by PsRestore.
+// Counts which are already saved are only replaced with force.
+func (d *DeisCmd) PsScale(appIDs []string, tag string, targets []string, save, force bool) error {
+	parsedTargets, err := parsePsTargets(targets)
+	if err != nil {
+		return err
+	}
+
+	s, appIDs, err := d.loadApps(appIDs, tag)
+	if err != nil {
+		return err
+	}
+
+	targetMaps := make(map[string]map[string]int)
+	saved := make(map[string]map[string]int)
+	if save {
+		if err = loadState(d.ConfigFile, savedScaleFile, &saved); err != nil {
+			return err
+		}
+
+		for _, appID := range appIDs {
+			if _, found := saved[appID]; found && !force {
+				return fmt.Errorf("Process counts for %s are already saved, restore them with 'deis ps:scale --restore' or replace them with --force", appID)
+			}
+		}
+	}
+
+	for _, appID := range appIDs {
+		var current map[string]int
+		if save || hasRelativeTargets(parsedTargets) {
+			if current, err = d.processCounts(s, appID); err != nil {
+				return err
+			}
+		}
+
+		targetMaps[appID] = resolvePsTargets(parsedTargets, current)
+		if save {
+			saved[appID] = current
+		}
+	}
+
+	if save {
+		if err = saveState(d.ConfigFile, savedScaleFile, saved); err != nil {
+			return err
+		}
+	}
+
+	for i, appID := range appIDs {
+		if i > 0 {
+			d.Println()
+		}
+
+		if err = d.doPsScale(s, appID, targetMaps[appID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PsRestore scales the processes of one or more apps back to the counts saved by PsScale.
+func (d *DeisCmd) PsRestore(appIDs []string, tag string) error {
+	s, appIDs, err := d.loadApps(appIDs, tag)
 	if err != nil {
 		return err
 	}
 
+	saved := make(map[string]map[string]int)
+	if err = loadState(d.ConfigFile, savedScaleFile, &saved); err != nil {
+		return err
+	}
+
+	for _, appID := range appIDs {
+		if _, found := saved[appID]; !found {
+			return fmt.Errorf("No saved process counts found for %s, use 'deis ps:scale --save' first", appID)
+		}
+	}
+
+	for i, appID := range appIDs {
+		if i > 0 {
+			d.Println()
+		}
+
+		if err = d.doPsScale(s, appID, saved[appID]); err != nil {
+			return err
+		}
+
+		delete(saved, appID)
+		if err = saveState(d.ConfigFile, savedScaleFile, saved); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DeisCmd) doPsScale(s *settings.Settings, appID string, targetMap map[string]int) error {
 	d.Printf("Scaling processes... but first, %s!\n", drinkOfChoice())
 	startTime := time.Now()
 	quit := progress(d.WOut)
 
-	err = ps.Scale(s.Client, appID, targetMap)
+	err := ps.Scale(s.Client, appID, targetMap)
 	quit <- true
 	<-quit
 	if d.checkAPICompatibility(s.Client, err) != nil {
@@ -64,10 +300,157 @@ func (d *DeisCmd) PsScale(appID string, targets []string) error {
 		return err
 	}
 
-	printProcesses(appID, processes, d.WOut)
+	printProcesses(appID, processes, time.Time{}, d.WOut)
+	return nil
+}
+
+// processCounts returns the number of processes of each type an app is scaled to. This is
+// the app's structure rather than the processes listed, which can include crashed or
+// terminating processes.
+func (d *DeisCmd) processCounts(s *settings.Settings, appID string) (map[string]int, error) {
+	counts, err := appStructure(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// appStructure fetches the number of processes of each type an app is scaled to. The SDK's
+// api.App leaves out the structure, so the app is decoded here.
+func appStructure(c *deis.Client, appID string) (map[string]int, error) {
+	res, reqErr := c.Request("GET", fmt.Sprintf("/v2/apps/%s/", appID), nil)
+	if reqErr != nil && reqErr != deis.ErrAPIMismatch {
+		return nil, reqErr
+	}
+	defer res.Body.Close()
+
+	app := struct {
+		Structure map[string]int `json:"structure"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&app); err != nil {
+		return nil, err
+	}
+
+	if app.Structure == nil {
+		app.Structure = make(map[string]int)
+	}
+
+	return app.Structure, reqErr
+}
+
+// scaleProfilesFile stores the scale profiles created with ps:schedule:set, keyed by app
+// and then by profile name.
+const scaleProfilesFile = "scale-profiles"
+
+// PsScheduleList lists an app's scale profiles.
+func (d *DeisCmd) PsScheduleList(appID string) error {
+	_, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	profiles := make(map[string]map[string][]string)
+	if err = loadState(d.ConfigFile, scaleProfilesFile, &profiles); err != nil {
+		return err
+	}
+
+	d.Printf("=== %s Scale Profiles\n", appID)
+
+	profileMap := make(map[string]string)
+	for name, targets := range profiles[appID] {
+		profileMap[name] = strings.Join(targets, " ")
+	}
+
+	d.Print(prettyprint.PrettyTabs(profileMap, 5))
+
+	return nil
+}
+
+// PsScheduleSet creates or replaces an app's scale profile.
+func (d *DeisCmd) PsScheduleSet(appID, profile string, targets []string) error {
+	if _, err := parsePsTargets(targets); err != nil {
+		return err
+	}
+
+	_, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	profiles := make(map[string]map[string][]string)
+	if err = loadState(d.ConfigFile, scaleProfilesFile, &profiles); err != nil {
+		return err
+	}
+
+	if profiles[appID] == nil {
+		profiles[appID] = make(map[string][]string)
+	}
+	profiles[appID][profile] = targets
+
+	if err = saveState(d.ConfigFile, scaleProfilesFile, profiles); err != nil {
+		return err
+	}
+
+	d.Printf("Saved scale profile %s for %s: %s\n", profile, appID, strings.Join(targets, " "))
+
+	return nil
+}
+
+// PsScheduleUnset removes scale profiles from an app.
+func (d *DeisCmd) PsScheduleUnset(appID string, names []string) error {
+	_, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	profiles := make(map[string]map[string][]string)
+	if err = loadState(d.ConfigFile, scaleProfilesFile, &profiles); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, found := profiles[appID][name]; !found {
+			return fmt.Errorf("Could not find scale profile %s for %s", name, appID)
+		}
+	}
+
+	for _, name := range names {
+		delete(profiles[appID], name)
+	}
+	if len(profiles[appID]) == 0 {
+		delete(profiles, appID)
+	}
+
+	if err = saveState(d.ConfigFile, scaleProfilesFile, profiles); err != nil {
+		return err
+	}
+
+	d.Printf("Removed scale profiles %s from %s\n", strings.Join(names, ", "), appID)
+
 	return nil
 }
 
+// PsApplyProfile scales an app to one of its scale profiles.
+func (d *DeisCmd) PsApplyProfile(appID, profile string) error {
+	_, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	profiles := make(map[string]map[string][]string)
+	if err = loadState(d.ConfigFile, scaleProfilesFile, &profiles); err != nil {
+		return err
+	}
+
+	targets, found := profiles[appID][profile]
+	if !found {
+		return fmt.Errorf("Could not find scale profile %s for %s, use 'deis ps:schedule:set' first", profile, appID)
+	}
+
+	return d.PsScale([]string{appID}, "", targets, false, false)
+}
+
 // PsRestart restarts an app's processes.
 func (d *DeisCmd) PsRestart(appID, target string) error {
 	s, appID, err := load(d.ConfigFile, appID)
@@ -97,13 +480,147 @@ func (d *DeisCmd) PsRestart(appID, target string) error {
 		d.Println("Could not find any processes to restart")
 	} else {
 		d.Printf("done in %ds\n", int(time.Since(startTime).Seconds()))
-		printProcesses(appID, processes, d.WOut)
+		printProcesses(appID, processes, time.Time{}, d.WOut)
+	}
+
+	return nil
+}
+
+// PsRollingRestart restarts an app's processes batchSize at a time, waiting for each batch
+// to come back up before restarting the next one.
+func (d *DeisCmd) PsRollingRestart(appID, target string, batchSize int, interval, timeout time.Duration) error {
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	psType := ""
+	if target != "" {
+		var psName string
+		psType, psName = parseType(target, appID)
+		if psName != "" {
+			return fmt.Errorf("Rolling restarts need a process type, not the process %s", target)
+		}
+	}
+
+	processes, err := listProcesses(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
 	}
 
+	var podTypes api.PodTypes
+	for _, podType := range ps.ByType(processes) {
+		if psType == "" || podType.Type == psType {
+			podTypes = append(podTypes, podType)
+		}
+	}
+
+	if len(podTypes) == 0 {
+		if psType != "" {
+			return fmt.Errorf("Could not find process type %s in app %s", psType, appID)
+		}
+		d.Println("Could not find any processes to restart")
+		return nil
+	}
+
+	startTime := time.Now()
+
+	for _, podType := range podTypes {
+		pods := podType.PodsList
+		for start := 0; start < len(pods); start += batchSize {
+			end := start + batchSize
+			if end > len(pods) {
+				end = len(pods)
+			}
+			batch := pods[start:end]
+
+			restarted := make(map[string]bool, len(batch))
+			names := make([]string, 0, len(batch))
+			for _, pod := range batch {
+				restarted[pod.Name] = true
+				names = append(names, pod.Name)
+			}
+
+			d.Printf("Restarting %s... ", strings.Join(names, ", "))
+
+			for _, pod := range batch {
+				_, err = ps.Restart(s.Client, appID, podType.Type, pod.Name)
+				if err == deis.ErrPodNotFound {
+					// the process went away on its own, its replacement is waited on below.
+					continue
+				} else if d.checkAPICompatibility(s.Client, err) != nil {
+					d.Println()
+					return err
+				}
+			}
+
+			err = d.waitForProcesses(s, appID, podType.Type, len(pods), restarted, interval, timeout)
+			if err != nil {
+				d.Println()
+				return fmt.Errorf("Aborting rolling restart of %s: %v", podType.Type, err)
+			}
+
+			d.Println("done")
+		}
+	}
+
+	d.Printf("Rolling restart done in %ds\n", int(time.Since(startTime).Seconds()))
+
+	processes, err = listProcesses(s.Client, appID, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+	printProcesses(appID, processes, time.Time{}, d.WOut)
+
 	return nil
 }
 
-func printProcesses(appID string, input []api.Pods, wOut io.Writer) {
+// waitForProcesses polls an app until none of the replaced processes remain and at least
+// count processes of psType are up. It fails if a process of psType crashes or the
+// processes are not up within timeout.
+func (d *DeisCmd) waitForProcesses(s *settings.Settings, appID, psType string, count int,
+	replaced map[string]bool, interval, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		processes, err := listProcesses(s.Client, appID, s.Limit)
+		if d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+
+		up, pending := 0, false
+		for _, pod := range processes {
+			if pod.Type != psType {
+				continue
+			}
+			if replaced[pod.Name] {
+				pending = true
+				continue
+			}
+
+			switch pod.State {
+			case "up":
+				up++
+			case "crashed", "error":
+				return fmt.Errorf("process %s is %s", pod.Name, pod.State)
+			}
+		}
+
+		if !pending && up >= count {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s processes were not up within %s", psType, timeout)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// printProcesses prints processes grouped by type. Unless now is zero, each process also
+// shows how long ago it was started.
+func printProcesses(appID string, input []api.Pods, now time.Time, wOut io.Writer) {
 	processes := ps.ByType(input)
 
 	fmt.Fprintf(wOut, "=== %s Processes\n", appID)
@@ -112,7 +629,12 @@ func printProcesses(appID string, input []api.Pods, wOut io.Writer) {
 		fmt.Fprintf(wOut, "--- %s:\n", process.Type)
 
 		for _, pod := range process.PodsList {
-			fmt.Fprintf(wOut, "%s %s (%s)\n", pod.Name, pod.State, pod.Release)
+			if now.IsZero() || pod.Started.Time == nil {
+				fmt.Fprintf(wOut, "%s %s (%s)\n", pod.Name, pod.State, pod.Release)
+			} else {
+				fmt.Fprintf(wOut, "%s %s (%s, started %s ago)\n", pod.Name, pod.State, pod.Release,
+					shortDuration(now.Sub(*pod.Started.Time)))
+			}
 		}
 	}
 }
@@ -140,23 +662,64 @@ func parseType(target string, appID string) (string, string) {
 	return psType, psName
 }
 
-func parsePsTargets(targets []string) (map[string]int, error) {
-	targetMap := make(map[string]int)
-	regex := regexp.MustCompile("^([a-z0-9]+)=([0-9]+)$")
-	var err error
+// psTarget is a process count to scale a process type to. If op is "+" or "-", num is
+// relative to the current number of processes.
+type psTarget struct {
+	procType string
+	op       string
+	num      int
+}
+
+func parsePsTargets(targets []string) ([]psTarget, error) {
+	var parsed []psTarget
+	regex := regexp.MustCompile("^([a-z0-9]+)([=+-])([0-9]+)$")
 
 	for _, target := range targets {
 		if regex.MatchString(target) {
 			captures := regex.FindStringSubmatch(target)
-			targetMap[captures[1]], err = strconv.Atoi(captures[2])
+			num, err := strconv.Atoi(captures[3])
 
 			if err != nil {
 				return nil, err
 			}
+
+			parsed = append(parsed, psTarget{procType: captures[1], op: captures[2], num: num})
 		} else {
-			return nil, fmt.Errorf("'%s' does not match the pattern 'type=num', ex: web=2\n", target)
+			return nil, fmt.Errorf("'%s' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n", target)
+		}
+	}
+
+	return parsed, nil
+}
+
+func hasRelativeTargets(targets []psTarget) bool {
+	for _, target := range targets {
+		if target.op != "=" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolvePsTargets turns targets into absolute process counts, using the current counts for
+// relative targets. Counts never go below zero.
+func resolvePsTargets(targets []psTarget, current map[string]int) map[string]int {
+	targetMap := make(map[string]int)
+
+	for _, target := range targets {
+		switch target.op {
+		case "+":
+			targetMap[target.procType] = current[target.procType] + target.num
+		case "-":
+			targetMap[target.procType] = current[target.procType] - target.num
+			if targetMap[target.procType] < 0 {
+				targetMap[target.procType] = 0
+			}
+		default:
+			targetMap[target.procType] = target.num
 		}
 	}
 
-	return targetMap, nil
+	return targetMap
 }
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	gotime "time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/controller-sdk-go/api"
@@ -58,13 +61,26 @@ func TestPrintProcesses(t *testing.T) {
 		},
 	}
 
-	printProcesses("appname", pods, &b)
+	printProcesses("appname", pods, gotime.Time{}, &b)
 
 	assert.Equal(t, b.String(), `=== appname Processes
 --- web:
 benign-quilting-web-4084101150-c871y up (v3)
 --- worker:
 benign-quilting-worker-4084101150-c871y up (v3)
+`, "output")
+
+	started := gotime.Date(2016, gotime.February, 13, 0, 47, 52, 0, gotime.UTC)
+	pods[0].Started = time.Time{Time: &started}
+	b.Reset()
+
+	printProcesses("appname", pods, started.Add(3*gotime.Hour), &b)
+
+	assert.Equal(t, b.String(), `=== appname Processes
+--- web:
+benign-quilting-web-4084101150-c871y up (v3, started 3h ago)
+--- worker:
+benign-quilting-worker-4084101150-c871y up (v3)
 `, "output")
 }
 
@@ -96,13 +112,228 @@ func TestPsList(t *testing.T) {
 		}`)
 	})
 
-	err = cmdr.PsList("foo", -1)
+	err = cmdr.PsList("foo", -1, gotime.Date(2016, gotime.February, 14, 0, 0, 0, 0, gotime.UTC))
 	assert.NoErr(t, err)
 
 	assert.Equal(t, b.String(), `=== foo Processes
 --- web:
+foo-web-4084101150-c871y up (v2, started 23h ago)
+`, "output")
+}
+
+func TestPsWatch(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	// polls is counted by the server, so it is guarded by mu.
+	var mu sync.Mutex
+	states := []string{"starting", "starting", "up"}
+	polls := 0
+	server.Mux.HandleFunc("/v2/apps/foo/pods/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 1,
+			"next": null,
+			"previous": null,
+			"results": [
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "foo-web-4084101150-c871y",
+					"state": "%s"
+				}
+			]
+		}`, states[polls])
+		polls++
+	})
+
+	now := gotime.Date(2016, gotime.February, 13, 1, 0, 0, 0, gotime.UTC)
+	clock := func() gotime.Time { return now }
+	err = cmdr.PsWatch("foo", gotime.Millisecond, 0, true, clock)
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, polls, 3, "polls")
+	mu.Unlock()
+
+	assert.Equal(t, b.String(), clearScreen+`=== foo Processes
+--- web:
+foo-web-4084101150-c871y starting (v2)
+`+clearScreen+`=== foo Processes
+--- web:
+foo-web-4084101150-c871y starting (v2)
+`+clearScreen+`=== foo Processes
+--- web:
 foo-web-4084101150-c871y up (v2)
+
+=== Changed
+foo-web-4084101150-c871y starting -> up
+
+All processes are up.
 `, "output")
+
+	server.Mux.HandleFunc("/v2/apps/bar/pods/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 2,
+			"next": null,
+			"previous": null,
+			"results": [
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "bar-web-4084101150-c871y",
+					"state": "up"
+				},
+				{
+					"release": "v2",
+					"type": "worker",
+					"name": "bar-worker-4084101150-a871x",
+					"state": "crashed"
+				}
+			]
+		}`)
+	})
+
+	err = cmdr.PsWatch("bar", gotime.Millisecond, 0, true, clock)
+	assert.Equal(t, err.Error(), "Not all processes will come up: bar-worker-4084101150-a871x is crashed", "error")
+
+	server.Mux.HandleFunc("/v2/apps/baz/pods/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 1,
+			"next": null,
+			"previous": null,
+			"results": [
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "baz-web-4084101150-c871y",
+					"state": "starting"
+				}
+			]
+		}`)
+	})
+
+	// every look at the clock is a minute later, so the watch gives up on the third poll.
+	ticks := 0
+	slowClock := func() gotime.Time {
+		ticks++
+		return now.Add(gotime.Duration(ticks) * gotime.Minute)
+	}
+	err = cmdr.PsWatch("baz", gotime.Millisecond, 3*gotime.Minute, true, slowClock)
+	assert.Equal(t, err.Error(), "Processes were not all up within 3m0s", "error")
+}
+
+func TestPsInfo(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/foo/pods/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		// the last process is only on the second page.
+		lastPod := ""
+		if r.URL.Query().Get("limit") == "3" {
+			lastPod = `,
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "foo-web-4084101150-z123q",
+					"state": "up",
+					"started": "2016-02-13T00:55:52"
+				}`
+		}
+		fmt.Fprintf(w, `{
+			"count": 3,
+			"next": null,
+			"previous": null,
+			"results": [
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "foo-web-4084101150-c871y",
+					"state": "up",
+					"started": "2016-02-13T00:47:52"
+				},
+				{
+					"release": "v2",
+					"type": "worker",
+					"name": "foo-worker-4084101150-a871x",
+					"state": "crashed",
+					"started": "2016-02-13T00:47:52"
+				}%s
+			]
+		}`, lastPod)
+	})
+
+	server.Mux.HandleFunc("/v2/apps/foo/config/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"owner": "jkirk",
+			"app": "foo",
+			"values": {},
+			"memory": {
+				"web": "512M"
+			},
+			"cpu": {},
+			"healthcheck": {
+				"web": {
+					"livenessProbe": {
+						"initialDelaySeconds": 50,
+						"timeoutSeconds": 50,
+						"periodSeconds": 10,
+						"successThreshold": 1,
+						"failureThreshold": 3,
+						"httpGet": {
+							"port": 5000,
+							"path": "/healthz"
+						}
+					}
+				}
+			},
+			"tags": {},
+			"registry": {},
+			"created": "2014-01-01T00:00:00UTC",
+			"updated": "2014-01-01T00:00:00UTC",
+			"uuid": "de1bf5b5-4a72-4f94-a10c-d2a3741cdf75"
+		}`)
+	})
+
+	now := gotime.Date(2016, gotime.February, 13, 1, 0, 0, 0, gotime.UTC)
+	err = cmdr.PsInfo("foo", "foo-web-4084101150-c871y", now)
+	assert.NoErr(t, err)
+
+	assert.Equal(t, b.String(), `=== foo-web-4084101150-c871y Process
+type:       web
+state:      up
+release:    v2
+started:    2016-02-13T00:47:52Z (12m ago)
+memory:     512M
+cpu:        Unlimited
+liveness:   httpGet /healthz on port 5000
+readiness:  Not configured
+`, "output")
+
+	b.Reset()
+	err = cmdr.PsInfo("foo", "foo-web-4084101150-z123q", now)
+	assert.NoErr(t, err)
+	assert.True(t, strings.HasPrefix(b.String(), "=== foo-web-4084101150-z123q Process\n"), "output")
+
+	err = cmdr.PsInfo("foo", "foo-web-ghost", now)
+	assert.Equal(t, err.Error(), "Could not find process foo-web-ghost in app foo", "error")
 }
 
 type psTargetCases struct {
@@ -115,11 +346,15 @@ type psTargetCases struct {
 func TestParsePsTargets(t *testing.T) {
 	t.Parallel()
 
+	current := map[string]int{"web": 3, "worker": 1}
 	cases := []psTargetCases{
-		{[]string{"test"}, true, nil, "'test' does not match the pattern 'type=num', ex: web=2\n"},
-		{[]string{"test=a"}, true, nil, "'test=a' does not match the pattern 'type=num', ex: web=2\n"},
-		{[]string{"test="}, true, nil, "'test=' does not match the pattern 'type=num', ex: web=2\n"},
+		{[]string{"test"}, true, nil, "'test' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n"},
+		{[]string{"test=a"}, true, nil, "'test=a' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n"},
+		{[]string{"test="}, true, nil, "'test=' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n"},
+		{[]string{"test*2"}, true, nil, "'test*2' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n"},
 		{[]string{"test=2"}, false, map[string]int{"test": 2}, ""},
+		{[]string{"web+2", "worker-1"}, false, map[string]int{"web": 5, "worker": 0}, ""},
+		{[]string{"worker-5", "cron+1"}, false, map[string]int{"worker": 0, "cron": 1}, ""},
 	}
 
 	for _, check := range cases {
@@ -128,7 +363,7 @@ func TestParsePsTargets(t *testing.T) {
 			assert.Equal(t, err.Error(), check.ExpectedMsg, "error")
 		} else {
 			assert.NoErr(t, err)
-			assert.Equal(t, actual, check.ExpectedMap, "error")
+			assert.Equal(t, resolvePsTargets(actual, current), check.ExpectedMap, "error")
 		}
 	}
 }
@@ -142,8 +377,8 @@ func TestPsScale(t *testing.T) {
 	defer server.Close()
 	var b bytes.Buffer
 	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
-	err = cmdr.PsScale("foo", []string{"test"})
-	assert.Equal(t, err.Error(), "'test' does not match the pattern 'type=num', ex: web=2\n", "error")
+	err = cmdr.PsScale([]string{"foo"}, "", []string{"test"}, false, false)
+	assert.Equal(t, err.Error(), "'test' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n", "error")
 
 	server.Mux.HandleFunc("/v2/apps/foo/pods/", func(w http.ResponseWriter, r *http.Request) {
 		testutil.SetHeaders(w)
@@ -170,7 +405,7 @@ func TestPsScale(t *testing.T) {
 	})
 
 	b.Reset()
-	err = cmdr.PsScale("foo", []string{"web=1"})
+	err = cmdr.PsScale([]string{"foo"}, "", []string{"web=1"}, false, false)
 	assert.NoErr(t, err)
 	assert.Equal(t, testutil.StripProgress(b.String()), `Scaling processes... but first, coffee!
 done in 0s
@@ -180,6 +415,193 @@ foo-web-4084101150-c871y up (v2)
 `, "output")
 }
 
+func TestPsScaleSaveRestore(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	for _, app := range []string{"foo", "bar"} {
+		app := app
+		server.Mux.HandleFunc("/v2/apps/"+app+"/pods/", func(w http.ResponseWriter, r *http.Request) {
+			testutil.SetHeaders(w)
+			fmt.Fprintf(w, `{
+				"count": 3,
+				"next": null,
+				"previous": null,
+				"results": [
+					{
+						"release": "v2",
+						"type": "web",
+						"name": "%[1]s-web-4084101150-c871y",
+						"state": "up"
+					},
+					{
+						"release": "v2",
+						"type": "web",
+						"name": "%[1]s-web-4084101150-d982z",
+						"state": "up"
+					},
+					{
+						"release": "v2",
+						"type": "worker",
+						"name": "%[1]s-worker-4084101150-e093a",
+						"state": "up"
+					},
+					{
+						"release": "v1",
+						"type": "worker",
+						"name": "%[1]s-worker-3973090049-f104b",
+						"state": "terminating"
+					}
+				]
+			}`, app)
+		})
+		server.Mux.HandleFunc("/v2/apps/"+app+"/", func(w http.ResponseWriter, r *http.Request) {
+			testutil.SetHeaders(w)
+			fmt.Fprintf(w, `{"id": "%s", "owner": "test", "structure": {"web": 2, "worker": 1}}`, app)
+		})
+	}
+
+	// scaled and expected are shared with the server, so they are guarded by mu.
+	var mu sync.Mutex
+	var scaled []string
+	expected := map[string]int{"web": 0, "worker": 2}
+	scaleHandler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.AssertBody(t, expected, r)
+		scaled = append(scaled, r.URL.Path)
+		testutil.SetHeaders(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	server.Mux.HandleFunc("/v2/apps/foo/scale/", scaleHandler)
+	server.Mux.HandleFunc("/v2/apps/bar/scale/", scaleHandler)
+
+	err = cmdr.PsScale([]string{"foo", "bar"}, "", []string{"web=0", "worker+1"}, true, false)
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, scaled, []string{"/v2/apps/foo/scale/", "/v2/apps/bar/scale/"}, "scaled apps")
+	// saving again would lose the counts saved before scaling down.
+	scaled = nil
+	mu.Unlock()
+
+	err = cmdr.PsScale([]string{"bar"}, "", []string{"web=0", "worker+1"}, true, false)
+	assert.Equal(t, err.Error(), "Process counts for bar are already saved, restore them with 'deis ps:scale --restore' or replace them with --force", "error")
+	mu.Lock()
+	assert.Equal(t, len(scaled), 0, "scaled apps")
+	mu.Unlock()
+
+	err = cmdr.PsScale([]string{"bar"}, "", []string{"web=0", "worker+1"}, true, true)
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, scaled, []string{"/v2/apps/bar/scale/"}, "scaled apps")
+	mu.Unlock()
+
+	err = cmdr.PsRestore([]string{"baz"}, "")
+	assert.Equal(t, err.Error(), "No saved process counts found for baz, use 'deis ps:scale --save' first", "error")
+
+	mu.Lock()
+	scaled = nil
+	expected = map[string]int{"web": 2, "worker": 1}
+	mu.Unlock()
+	err = cmdr.PsRestore([]string{"foo"}, "")
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, scaled, []string{"/v2/apps/foo/scale/"}, "restored apps")
+	mu.Unlock()
+
+	err = cmdr.PsRestore([]string{"foo"}, "")
+	assert.Equal(t, err.Error(), "No saved process counts found for foo, use 'deis ps:scale --save' first", "error")
+}
+
+func TestPsSchedule(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/foo/pods/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{
+			"count": 1,
+			"next": null,
+			"previous": null,
+			"results": [
+				{
+					"release": "v2",
+					"type": "web",
+					"name": "foo-web-4084101150-c871y",
+					"state": "up"
+				}
+			]
+		}`)
+	})
+
+	// scaled is recorded by the server, so it is guarded by mu.
+	var mu sync.Mutex
+	var scaled []string
+	server.Mux.HandleFunc("/v2/apps/foo/scale/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.AssertBody(t, map[string]int{"web": 6, "worker": 3}, r)
+		scaled = append(scaled, r.URL.Path)
+		testutil.SetHeaders(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err = cmdr.PsScheduleSet("foo", "business-hours", []string{"web=6", "worker=3"})
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "Saved scale profile business-hours for foo: web=6 worker=3\n", "output")
+
+	err = cmdr.PsScheduleSet("foo", "night", []string{"web=1", "worker=0"})
+	assert.NoErr(t, err)
+
+	err = cmdr.PsScheduleSet("foo", "broken", []string{"web"})
+	assert.Equal(t, err.Error(), "'web' does not match the pattern 'type=num', 'type+num' or 'type-num', ex: web=2\n", "error")
+
+	b.Reset()
+	err = cmdr.PsScheduleList("foo")
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== foo Scale Profiles
+business-hours     web=6 worker=3
+night              web=1 worker=0
+`, "output")
+
+	b.Reset()
+	err = cmdr.PsApplyProfile("foo", "business-hours")
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, scaled, []string{"/v2/apps/foo/scale/"}, "scaled apps")
+	mu.Unlock()
+
+	err = cmdr.PsApplyProfile("foo", "weekend")
+	assert.Equal(t, err.Error(), "Could not find scale profile weekend for foo, use 'deis ps:schedule:set' first", "error")
+
+	err = cmdr.PsScheduleUnset("foo", []string{"night", "weekend"})
+	assert.Equal(t, err.Error(), "Could not find scale profile weekend for foo", "error")
+
+	b.Reset()
+	err = cmdr.PsScheduleUnset("foo", []string{"night"})
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), "Removed scale profiles night from foo\n", "output")
+
+	b.Reset()
+	err = cmdr.PsScheduleList("foo")
+	assert.NoErr(t, err)
+	assert.Equal(t, b.String(), `=== foo Scale Profiles
+business-hours     web=6 worker=3
+`, "output")
+}
+
 func TestPsRestart(t *testing.T) {
 	t.Parallel()
 	cf, server, err := testutil.NewTestServerAndClient()
@@ -287,3 +709,120 @@ newapp-web-4084101150-c871y up (v2)
 	err = cmdr.PsRestart("newapp", "ghost")
 	assert.Equal(t, err.Error(), "Could not find process type ghost in app newapp", "error")
 }
+
+func TestPsRollingRestart(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+
+	pod := func(name, state string) string {
+		return fmt.Sprintf(`{"release": "v2", "type": "web", "name": "foo-web-4084101150-%s", "state": "%s", "started": "2016-02-13T00:47:52"}`, name, state)
+	}
+	// each call to the pods endpoint returns the next list of pods.
+	lists := []string{
+		pod("aaaaa", "up") + "," + pod("bbbbb", "up"),
+		pod("bbbbb", "up") + "," + pod("ccccc", "starting"),
+		pod("bbbbb", "up") + "," + pod("ccccc", "up"),
+		pod("ccccc", "up") + "," + pod("ddddd", "up"),
+		pod("ccccc", "up") + "," + pod("ddddd", "up"),
+	}
+	var restarted []string
+	// the handlers change the state of the fake app on the server's goroutines, so it is
+	// guarded by mu.
+	var mu sync.Mutex
+
+	server.Mux.HandleFunc("/v2/apps/foo/pods/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"count": 2, "next": null, "previous": null, "results": [%s]}`, lists[0])
+		lists = lists[1:]
+	})
+	server.Mux.HandleFunc("/v2/apps/foo/pods/web/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		restarted = append(restarted, r.URL.Path)
+		fmt.Fprintf(w, `[]`)
+	})
+
+	err = cmdr.PsRollingRestart("foo", "web", 1, gotime.Millisecond, gotime.Minute)
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, restarted, []string{
+		"/v2/apps/foo/pods/web/foo-web-4084101150-aaaaa/restart/",
+		"/v2/apps/foo/pods/web/foo-web-4084101150-bbbbb/restart/",
+	}, "restarted")
+	mu.Unlock()
+	assert.Equal(t, b.String(), `Restarting foo-web-4084101150-aaaaa... done
+Restarting foo-web-4084101150-bbbbb... done
+Rolling restart done in 0s
+=== foo Processes
+--- web:
+foo-web-4084101150-ccccc up (v2)
+foo-web-4084101150-ddddd up (v2)
+`, "output")
+
+	crashed := []string{
+		pod("aaaaa", "up"),
+		pod("bbbbb", "crashed"),
+	}
+	server.Mux.HandleFunc("/v2/apps/bar/pods/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `{"count": 1, "next": null, "previous": null, "results": [%s]}`, crashed[0])
+		crashed = crashed[1:]
+	})
+	server.Mux.HandleFunc("/v2/apps/bar/pods/web/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		fmt.Fprintf(w, `[]`)
+	})
+
+	b.Reset()
+	err = cmdr.PsRollingRestart("bar", "web", 1, gotime.Millisecond, gotime.Minute)
+	assert.Equal(t, err.Error(), "Aborting rolling restart of web: process foo-web-4084101150-bbbbb is crashed", "error")
+	assert.Equal(t, b.String(), "Restarting foo-web-4084101150-aaaaa... \n", "output")
+
+	err = cmdr.PsRollingRestart("foo", "web-4084101150-ccccc", 1, gotime.Millisecond, gotime.Minute)
+	assert.Equal(t, err.Error(), "Rolling restarts need a process type, not the process web-4084101150-ccccc", "error")
+
+	// the second process is only on the second page, both before and after the restart.
+	var pagedRestarts int
+	server.Mux.HandleFunc("/v2/apps/paged/pods/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		pods := []string{pod("aaaaa", "up"), pod("bbbbb", "up")}
+		if pagedRestarts == 2 {
+			pods = []string{pod("ccccc", "up"), pod("ddddd", "up")}
+		}
+		if r.URL.Query().Get("limit") != "2" {
+			pods = pods[:1]
+		}
+		fmt.Fprintf(w, `{"count": 2, "next": null, "previous": null, "results": [%s]}`, strings.Join(pods, ","))
+	})
+	server.Mux.HandleFunc("/v2/apps/paged/pods/web/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		testutil.SetHeaders(w)
+		pagedRestarts++
+		fmt.Fprintf(w, `[]`)
+	})
+
+	b.Reset()
+	err = cmdr.PsRollingRestart("paged", "web", 2, gotime.Millisecond, gotime.Second)
+	assert.NoErr(t, err)
+	mu.Lock()
+	assert.Equal(t, pagedRestarts, 2, "restarts")
+	mu.Unlock()
+	assert.True(t, strings.HasSuffix(b.String(), `--- web:
+foo-web-4084101150-ccccc up (v2)
+foo-web-4084101150-ddddd up (v2)
+`), "output")
+}
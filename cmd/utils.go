@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	deis "github.com/deis/controller-sdk-go"
+	"github.com/deis/controller-sdk-go/api"
+	"github.com/deis/controller-sdk-go/apps"
+	"github.com/deis/controller-sdk-go/config"
 	"github.com/deis/workflow-cli/pkg/git"
 	"github.com/deis/workflow-cli/settings"
 )
@@ -56,6 +63,144 @@ func load(cf string, appID string) (*settings.Settings, string, error) {
 	return s, appID, nil
 }
 
+// loadApps loads the settings file and looks up the names of the apps to operate on. If a
+// key=value tag is given, every app with that tag is selected.
+func (d *DeisCmd) loadApps(appIDs []string, tag string) (*settings.Settings, []string, error) {
+	if tag == "" {
+		if len(appIDs) > 1 {
+			s, err := settings.Load(d.ConfigFile)
+			return s, appIDs, err
+		}
+
+		appID := ""
+		if len(appIDs) == 1 {
+			appID = appIDs[0]
+		}
+
+		s, appID, err := load(d.ConfigFile, appID)
+		return s, []string{appID}, err
+	}
+
+	if len(appIDs) > 0 {
+		return nil, nil, errors.New("Apps can be given by name or by tag, but not both")
+	}
+
+	wanted, err := parseTags([]string{tag})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := settings.Load(d.ConfigFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appList, count, err := apps.List(s.Client, s.Limit)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return nil, nil, err
+	}
+	if count > len(appList) {
+		if appList, _, err = apps.List(s.Client, count); d.checkAPICompatibility(s.Client, err) != nil {
+			return nil, nil, err
+		}
+	}
+
+	appList, err = d.filterAppsByTags(s.Client, appList, wanted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(appList) == 0 {
+		return nil, nil, fmt.Errorf("No apps found with tag %s", tag)
+	}
+
+	tagged := make([]string, len(appList))
+	for i, app := range appList {
+		tagged[i] = app.ID
+	}
+
+	return s, tagged, nil
+}
+
+// appWorkers is how many apps are queried at once when apps are filtered by tag.
+const appWorkers = 4
+
+// forEachApp calls fn with the index of each of count apps from a pool of workers, so that
+// at most workers calls run at once. It returns once every call has.
+func forEachApp(count, workers int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// filterAppsByTags returns the apps with every wanted tag, in the order given. The apps'
+// tags are fetched appWorkers apps at a time.
+func (d *DeisCmd) filterAppsByTags(c *deis.Client, appList api.Apps, wanted map[string]interface{}) (api.Apps, error) {
+	configs := make([]api.Config, len(appList))
+	errs := make([]error, len(appList))
+	forEachApp(len(appList), appWorkers, func(i int) {
+		configs[i], errs[i] = config.List(c, appList[i].ID)
+	})
+
+	var tagged api.Apps
+	for i, app := range appList {
+		if d.checkAPICompatibility(c, errs[i]) != nil {
+			return nil, errs[i]
+		}
+		if tagsMatch(configs[i].Tags, wanted) {
+			tagged = append(tagged, app)
+		}
+	}
+
+	return tagged, nil
+}
+
+// tagsMatch reports whether an app's tags have every wanted key and value.
+func tagsMatch(tags, wanted map[string]interface{}) bool {
+	for key, value := range wanted {
+		if tag, found := tags[key]; !found || fmt.Sprint(tag) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadState reads client-side state saved by saveState into v. Missing state is not an error.
+func loadState(cf, name string, v interface{}) error {
+	contents, err := ioutil.ReadFile(settings.StatePath(cf, name))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(contents, v)
+}
+
+// saveState stores client-side state, such as saved process counts, next to the settings file.
+func saveState(cf, name string, v interface{}) error {
+	contents, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(settings.StatePath(cf, name), contents, 0600)
+}
+
 func drinkOfChoice() string {
 	drink := os.Getenv("DEIS_DRINK_OF_CHOICE")
 
@@ -74,6 +219,20 @@ func limitCount(objs, total int) string {
 	return fmt.Sprintf(" (%d of %d)\n", objs, total)
 }
 
+// shortDuration formats a duration in its largest whole unit, such as 45s, 12m, 3h or 5d.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
 // checkAPICompatibility handles specific behavior for certain errors,
 // such as printing an warning for the API mismatch error
 func (d *DeisCmd) checkAPICompatibility(c *deis.Client, err error) error {
@@ -2,14 +2,19 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/controller-sdk-go"
 	"github.com/deis/workflow-cli/pkg/git"
+	"github.com/deis/workflow-cli/pkg/testutil"
 	"github.com/deis/workflow-cli/settings"
 )
 
@@ -85,3 +90,99 @@ func TestAPICompatibility(t *testing.T) {
 	assert.Err(t, deis.ErrConflict, err)
 	assert.Equal(t, b.String(), "", "output")
 }
+
+func TestShortDuration(t *testing.T) {
+	t.Parallel()
+
+	cases := map[time.Duration]string{
+		0:                             "0s",
+		45 * time.Second:              "45s",
+		12*time.Minute + time.Second:  "12m",
+		3*time.Hour + 59*time.Minute:  "3h",
+		5*24*time.Hour + 23*time.Hour: "5d",
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, shortDuration(input), expected, "duration")
+	}
+}
+
+func TestLoadAppsByTag(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	cmdr := DeisCmd{ConfigFile: cf}
+
+	server.Mux.HandleFunc("/v2/apps/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		// the last app is only on the second page.
+		lastApp := ""
+		if r.URL.Query().Get("limit") == "3" {
+			lastApp = `,
+				{"id": "baz", "owner": "test"}`
+		}
+		fmt.Fprintf(w, `{
+			"count": 3,
+			"next": null,
+			"previous": null,
+			"results": [
+				{"id": "foo", "owner": "test"},
+				{"id": "bar", "owner": "test"}%s
+			]
+		}`, lastApp)
+	})
+
+	for app, environ := range map[string]string{"foo": "staging", "bar": "production", "baz": "staging"} {
+		environ := environ
+		server.Mux.HandleFunc("/v2/apps/"+app+"/config/", func(w http.ResponseWriter, r *http.Request) {
+			testutil.SetHeaders(w)
+			fmt.Fprintf(w, `{"tags": {"environ": "%s"}}`, environ)
+		})
+	}
+
+	_, appIDs, err := cmdr.loadApps(nil, "environ=staging")
+	assert.NoErr(t, err)
+	assert.Equal(t, appIDs, []string{"foo", "baz"}, "apps")
+
+	_, _, err = cmdr.loadApps(nil, "environ=test")
+	assert.Equal(t, err.Error(), "No apps found with tag environ=test", "error")
+
+	_, _, err = cmdr.loadApps(nil, "environ")
+	assert.Equal(t, err.Error(), "environ is invalid, Must be in format key=value\nExamples: rack=1 evironment=production", "error")
+
+	_, _, err = cmdr.loadApps([]string{"foo"}, "environ=staging")
+	assert.Equal(t, err.Error(), "Apps can be given by name or by tag, but not both", "error")
+
+	_, appIDs, err = cmdr.loadApps([]string{"foo", "bar"}, "")
+	assert.NoErr(t, err)
+	assert.Equal(t, appIDs, []string{"foo", "bar"}, "apps")
+}
+
+func TestForEachApp(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	called := make([]int, 10)
+	inFlight, maxInFlight := 0, 0
+	forEachApp(len(called), 3, func(i int) {
+		mu.Lock()
+		called[i]++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	})
+
+	assert.Equal(t, called, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, "calls per app")
+	assert.True(t, maxInFlight <= 3, fmt.Sprintf("%d calls at once by 3 workers", maxInFlight))
+}
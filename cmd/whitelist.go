@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"strings"
 
 	"github.com/deis/controller-sdk-go/whitelist"
@@ -27,18 +33,33 @@ func (d *DeisCmd) WhitelistList(appID string) error {
 	return nil
 }
 
-// WhitelistAdd adds the addresses to the app's Whitelist.
+// WhitelistAdd adds the addresses to the app's Whitelist. Addresses which are already
+// covered by the whitelist or by each other are warned about.
 func (d *DeisCmd) WhitelistAdd(appID, IPs string) error {
+	addresses, err := parseAddresses(IPs)
+	if err != nil {
+		return err
+	}
+
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
 		return err
 	}
 
-	d.Printf("Adding %s to %s whitelist...\n", IPs, appID)
+	current, err := whitelist.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	for _, overlap := range addressOverlaps(current.Addresses, addresses) {
+		d.PrintErrf("Warning: %s\n", overlap)
+	}
+
+	d.Printf("Adding %s to %s whitelist...\n", strings.Join(addresses, ","), appID)
 
 	quit := progress(d.WOut)
-	_, err = whitelist.Add(s.Client, appID, strings.Split(IPs, ","))
+	_, err = whitelist.Add(s.Client, appID, addresses)
 	quit <- true
 	<-quit
 	if d.checkAPICompatibility(s.Client, err) != nil {
@@ -51,16 +72,21 @@ func (d *DeisCmd) WhitelistAdd(appID, IPs string) error {
 
 // WhitelistRemove deletes the addresses from the app's Whitelist.
 func (d *DeisCmd) WhitelistRemove(appID, IPs string) error {
+	addresses, err := parseAddresses(IPs)
+	if err != nil {
+		return err
+	}
+
 	s, appID, err := load(d.ConfigFile, appID)
 
 	if err != nil {
 		return err
 	}
 
-	d.Printf("Removing %s from %s whitelist...\n", IPs, appID)
+	d.Printf("Removing %s from %s whitelist...\n", strings.Join(addresses, ","), appID)
 
 	quit := progress(d.WOut)
-	err = whitelist.Delete(s.Client, appID, strings.Split(IPs, ","))
+	err = whitelist.Delete(s.Client, appID, addresses)
 	quit <- true
 	<-quit
 	if d.checkAPICompatibility(s.Client, err) != nil {
@@ -70,3 +96,248 @@ func (d *DeisCmd) WhitelistRemove(appID, IPs string) error {
 	d.Println("done")
 	return nil
 }
+
+// WhitelistSync makes the app's whitelist match the addresses listed in a file. An address
+// and the single address range it spells, such as 1.2.3.4 and 1.2.3.4/32, are the same. The
+// changes are previewed and confirmed unless yes is set, and a file listing no addresses,
+// which would remove every address, is refused unless yes is set.
+func (d *DeisCmd) WhitelistSync(appID, file string, yes bool) error {
+	wanted, err := readAddressesFile(file)
+	if err != nil {
+		return err
+	}
+
+	s, appID, err := load(d.ConfigFile, appID)
+	if err != nil {
+		return err
+	}
+
+	if len(wanted) == 0 && !yes {
+		return fmt.Errorf("%s lists no addresses, pass --yes to remove every address from the whitelist of %s", file, appID)
+	}
+
+	current, err := whitelist.List(s.Client, appID)
+	if d.checkAPICompatibility(s.Client, err) != nil {
+		return err
+	}
+
+	for _, overlap := range addressOverlaps(nil, wanted) {
+		d.PrintErrf("Warning: %s\n", overlap)
+	}
+
+	existing := make(map[string]bool, len(current.Addresses))
+	for _, address := range current.Addresses {
+		existing[addressKey(address)] = true
+	}
+
+	var added, removed []string
+	listed := make(map[string]bool, len(wanted))
+	for _, address := range wanted {
+		listed[addressKey(address)] = true
+		if !existing[addressKey(address)] {
+			added = append(added, address)
+		}
+	}
+	for _, address := range current.Addresses {
+		if !listed[addressKey(address)] {
+			removed = append(removed, address)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		d.Printf("The whitelist of %s already matches %s\n", appID, file)
+		return nil
+	}
+
+	d.Printf("=== %s Whitelist Changes\n", appID)
+	for _, address := range added {
+		d.Printf("+ %s\n", address)
+	}
+	for _, address := range removed {
+		d.Printf("- %s\n", address)
+	}
+
+	if !yes {
+		confirm := ""
+		d.Printf("add %d and remove %d address(es)? (y/N): ", len(added), len(removed))
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" {
+			d.PrintErrln("Whitelist not changed")
+			return nil
+		}
+	}
+
+	d.Printf("Syncing whitelist of %s with %s...\n", appID, file)
+
+	// addresses are added before any are removed, so access isn't cut off in between.
+	if len(added) > 0 {
+		if _, err = whitelist.Add(s.Client, appID, added); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+		for _, address := range added {
+			d.Printf("%s: added\n", address)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err = whitelist.Delete(s.Client, appID, removed); d.checkAPICompatibility(s.Client, err) != nil {
+			return err
+		}
+		for _, address := range removed {
+			d.Printf("%s: removed\n", address)
+		}
+	}
+
+	d.Printf("\nAdded %d, removed %d, unchanged %d.\n", len(added), len(removed), len(wanted)-len(added))
+
+	return nil
+}
+
+// readAddressesFile reads the addresses listed in a file, one per line. Blank lines, lines
+// starting with # and addresses for a range listed earlier are skipped. Every invalid
+// address is reported at once.
+func readAddressesFile(file string) ([]string, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses, problems []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for line := 1; scanner.Scan(); line++ {
+		address := strings.TrimSpace(scanner.Text())
+		if address == "" || strings.HasPrefix(address, "#") {
+			continue
+		}
+
+		if _, err := parseAddress(address); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		if seen[addressKey(address)] {
+			continue
+		}
+		seen[addressKey(address)] = true
+		addresses = append(addresses, address)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s has invalid addresses:\n%s", file, strings.Join(problems, "\n"))
+	}
+
+	return addresses, nil
+}
+
+// parseAddresses splits a comma-separated list of addresses, checking each of them. Every
+// invalid address is reported at once.
+func parseAddresses(IPs string) ([]string, error) {
+	var addresses, problems []string
+	for _, address := range strings.Split(IPs, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+
+		if _, err := parseAddress(address); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("no addresses given")
+	}
+
+	return addresses, nil
+}
+
+// parseAddress parses an IPv4 or IPv6 address or CIDR range into the range it covers. A
+// single address covers a /32 or /128 range.
+func parseAddress(address string) (*net.IPNet, error) {
+	if strings.Contains(address, "/") {
+		ip, ipNet, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid CIDR range", address)
+		}
+		if !ip.Equal(ipNet.IP) {
+			return nil, fmt.Errorf("%s has host bits set, did you mean %s?", address, ipNet)
+		}
+
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("%s is not a valid IP address", address)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// addressKey returns the range an address covers in CIDR notation, or the address itself if
+// it can't be parsed, so that equal ranges compare equal.
+func addressKey(address string) string {
+	ipNet, err := parseAddress(address)
+	if err != nil {
+		return address
+	}
+
+	return ipNet.String()
+}
+
+// addressOverlaps describes each added address which is redundant, because the same range is
+// already listed or it falls within a larger listed range.
+func addressOverlaps(existing, added []string) []string {
+	addresses := append(append([]string{}, existing...), added...)
+	ranges := make([]*net.IPNet, len(addresses))
+	for i, address := range addresses {
+		ranges[i], _ = parseAddress(address)
+	}
+
+	var overlaps []string
+	for i := len(existing); i < len(addresses); i++ {
+		if ranges[i] == nil {
+			continue
+		}
+		innerOnes, innerBits := ranges[i].Mask.Size()
+
+		for j, outer := range ranges {
+			if j == i || outer == nil {
+				continue
+			}
+
+			outerOnes, outerBits := outer.Mask.Size()
+			if outerBits != innerBits || outerOnes > innerOnes || !outer.Contains(ranges[i].IP) {
+				continue
+			}
+
+			// equal ranges are reported once, on the later address.
+			if outerOnes == innerOnes {
+				if j > i {
+					continue
+				}
+				overlaps = append(overlaps, fmt.Sprintf("%s is the same range as %s", addresses[i], addresses[j]))
+			} else {
+				overlaps = append(overlaps, fmt.Sprintf("%s is already covered by %s", addresses[i], addresses[j]))
+			}
+			break
+		}
+	}
+
+	return overlaps
+}
@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/arschles/assert"
@@ -41,21 +45,131 @@ func TestWhitelistAdd(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer server.Close()
-	var b bytes.Buffer
-	cmdr := DeisCmd{WOut: &b, ConfigFile: cf}
+	var b, e bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, WErr: &e, ConfigFile: cf}
 
 	server.Mux.HandleFunc("/v2/apps/foo/whitelist/", func(w http.ResponseWriter, r *http.Request) {
-		testutil.AssertBody(t, api.Whitelist{Addresses: []string{"1.2.3.4", "0.0.0.0/0"}}, r)
 		testutil.SetHeaders(w)
+		if r.Method == "GET" {
+			fmt.Fprintf(w, `{"addresses": ["10.0.0.0/8"]}`)
+			return
+		}
+
+		testutil.AssertBody(t, api.Whitelist{Addresses: []string{"1.2.3.4", "10.1.0.0/16", "2001:db8::/32"}}, r)
 		w.WriteHeader(http.StatusCreated)
 		// Body isn't used by CLI, so it isn't set.
 		w.Write([]byte("{}"))
 	})
 
-	err = cmdr.WhitelistAdd("foo", "1.2.3.4,0.0.0.0/0")
+	err = cmdr.WhitelistAdd("foo", "1.2.3.4, 10.1.0.0/16,2001:db8::/32")
 	assert.NoErr(t, err)
 
-	assert.Equal(t, testutil.StripProgress(b.String()), "Adding 1.2.3.4,0.0.0.0/0 to foo whitelist...\ndone\n", "output")
+	assert.Equal(t, testutil.StripProgress(b.String()), "Adding 1.2.3.4,10.1.0.0/16,2001:db8::/32 to foo whitelist...\ndone\n", "output")
+	assert.Equal(t, e.String(), "Warning: 10.1.0.0/16 is already covered by 10.0.0.0/8\n", "stderr")
+
+	err = cmdr.WhitelistAdd("foo", "1.2.3.400,10.1.2.3/16,::1")
+	assert.Equal(t, err.Error(), `1.2.3.400 is not a valid IP address
+10.1.2.3/16 has host bits set, did you mean 10.1.0.0/16?`, "error")
+}
+
+func TestWhitelistSync(t *testing.T) {
+	t.Parallel()
+	cf, server, err := testutil.NewTestServerAndClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	var b, e bytes.Buffer
+	cmdr := DeisCmd{WOut: &b, WErr: &e, ConfigFile: cf}
+
+	file, err := ioutil.TempFile("", "deis-cli-unit-test-whitelist")
+	assert.NoErr(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`# offices
+1.2.3.4/32
+10.0.0.0/8
+1.2.3.4
+
+10.2.0.0/16
+2001:db8::1
+`)
+	assert.NoErr(t, err)
+	file.Close()
+
+	var requests []string
+	server.Mux.HandleFunc("/v2/apps/foo/whitelist/", func(w http.ResponseWriter, r *http.Request) {
+		testutil.SetHeaders(w)
+		if r.Method == "GET" {
+			fmt.Fprintf(w, `{"addresses": ["1.2.3.4", "0.0.0.0/0", "10.0.0.0/8"]}`)
+			return
+		}
+
+		var body api.Whitelist
+		assert.NoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, r.Method+" "+strings.Join(body.Addresses, ","))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	})
+
+	// without --yes, the changes are only previewed when they aren't confirmed.
+	err = cmdr.WhitelistSync("foo", file.Name(), false)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(requests), 0, "requests")
+	assert.Equal(t, b.String(), `=== foo Whitelist Changes
++ 10.2.0.0/16
++ 2001:db8::1
+- 0.0.0.0/0
+add 2 and remove 1 address(es)? (y/N): `, "output")
+	assert.True(t, strings.HasSuffix(e.String(), "Whitelist not changed\n"), "stderr")
+
+	b.Reset()
+	e.Reset()
+	err = cmdr.WhitelistSync("foo", file.Name(), true)
+	assert.NoErr(t, err)
+
+	assert.Equal(t, requests, []string{"POST 10.2.0.0/16,2001:db8::1", "DELETE 0.0.0.0/0"}, "requests")
+	assert.Equal(t, b.String(), fmt.Sprintf(`=== foo Whitelist Changes
++ 10.2.0.0/16
++ 2001:db8::1
+- 0.0.0.0/0
+Syncing whitelist of foo with %s...
+10.2.0.0/16: added
+2001:db8::1: added
+0.0.0.0/0: removed
+
+Added 2, removed 1, unchanged 2.
+`, file.Name()), "output")
+	assert.Equal(t, e.String(), "Warning: 10.2.0.0/16 is already covered by 10.0.0.0/8\n", "stderr")
+
+	// a file without addresses would remove them all, so it needs --yes.
+	requests = nil
+	assert.NoErr(t, ioutil.WriteFile(file.Name(), []byte("# nothing yet\n\n"), 0600))
+	err = cmdr.WhitelistSync("foo", file.Name(), false)
+	assert.Err(t, fmt.Errorf("%s lists no addresses, pass --yes to remove every address from the whitelist of foo", file.Name()), err)
+	assert.Equal(t, len(requests), 0, "requests")
+
+	err = cmdr.WhitelistSync("foo", file.Name(), true)
+	assert.NoErr(t, err)
+	assert.Equal(t, requests, []string{"DELETE 1.2.3.4,0.0.0.0/0,10.0.0.0/8"}, "requests")
+
+	assert.NoErr(t, ioutil.WriteFile(file.Name(), []byte("1.2.3.4\n1.2.3\n::1/129\n"), 0600))
+	err = cmdr.WhitelistSync("foo", file.Name(), true)
+	assert.Equal(t, err.Error(), fmt.Sprintf(`%s has invalid addresses:
+line 2: 1.2.3 is not a valid IP address
+line 3: ::1/129 is not a valid CIDR range`, file.Name()), "error")
+}
+
+func TestAddressOverlaps(t *testing.T) {
+	t.Parallel()
+
+	overlaps := addressOverlaps([]string{"10.0.0.0/8", "1.2.3.4"},
+		[]string{"10.1.2.3", "1.2.3.4/32", "192.168.0.0/16", "192.168.0.0/24", "::ffff:0:0/96", "2001:db8::/32", "2001:db8::/32"})
+	assert.Equal(t, overlaps, []string{
+		"10.1.2.3 is already covered by 10.0.0.0/8",
+		"1.2.3.4/32 is the same range as 1.2.3.4",
+		"192.168.0.0/24 is already covered by 192.168.0.0/16",
+		"2001:db8::/32 is the same range as 2001:db8::/32",
+	}, "overlaps")
 }
 
 func TestWhitelistRemove(t *testing.T) {
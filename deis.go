@@ -172,6 +172,9 @@ Use 'git push deis master' to deploy to an application.
 		}
 	}
 	if err != nil {
+		if exitErr, ok := err.(cmd.ExitCodeError); ok {
+			return exitErr.Code
+		}
 		fmt.Fprintf(wErr, "Error: %v\n", err)
 		return 1
 	}
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/deis/workflow-cli/cmd"
 	docopt "github.com/docopt/docopt-go"
@@ -15,12 +18,14 @@ Valid commands for apps:
 
 apps:create        create a new application
 apps:list          list accessible applications
+apps:status        view a health summary of all accessible applications
 apps:info          view info about an application
 apps:open          open the application in a browser
 apps:logs          view aggregated application logs
 apps:run           run a command in an ephemeral app container
 apps:destroy       destroy an application
 apps:transfer      transfer app ownership to another user
+apps:cost          estimate what an application costs per month
 
 Use 'deis help [command]' to learn more.
 `
@@ -30,6 +35,8 @@ Use 'deis help [command]' to learn more.
 		return appCreate(argv, cmdr)
 	case "apps:list":
 		return appsList(argv, cmdr)
+	case "apps:status":
+		return appsStatus(argv, cmdr)
 	case "apps:info":
 		return appInfo(argv, cmdr)
 	case "apps:open":
@@ -42,6 +49,8 @@ Use 'deis help [command]' to learn more.
 		return appDestroy(argv, cmdr)
 	case "apps:transfer":
 		return appTransfer(argv, cmdr)
+	case "apps:cost":
+		return appCost(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -97,11 +106,27 @@ func appsList(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Lists applications visible to the current user.
 
-Usage: deis apps:list [options]
+Applications can be filtered and sorted, which helps to find them among many. The limit
+applies to the applications which match the filters.
+
+Usage: deis apps:list [options] [--tag=<tag>...]
 
 Options:
   -l --limit=<num>
     the maximum number of results to display, defaults to config setting
+  --owner=<owner>
+    only list applications owned by this user.
+  --name=<pattern>
+    only list applications whose name matches this pattern, such as 'pay-*'.
+  --created-before=<date>
+    only list applications created before this date, such as 2016-01-31, or longer than
+    this age ago, such as 90d.
+  --tag=<tag>
+    only list applications with this tag, as set with tags:set, such as environment=prod.
+  --sort=<key>
+    sort applications by name, owner, created or updated.
+  --reverse
+    sort in reverse order, requires --sort.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -116,7 +141,43 @@ Options:
 		return err
 	}
 
-	return cmdr.AppsList(results)
+	var createdBefore time.Time
+	if date := safeGetValue(args, "--created-before"); date != "" {
+		if createdBefore, err = parseDateOrAge("--created-before", date, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return cmdr.AppsList(results, safeGetValue(args, "--owner"), safeGetValue(args, "--name"), createdBefore,
+		args["--tag"].([]string), safeGetValue(args, "--sort"), args["--reverse"].(bool))
+}
+
+func appsStatus(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Prints a summary of every application visible to the current user: how many processes
+are up, the latest release, whether it is routable, in maintenance or enforces HTTPS,
+and how many domains it has. Applications with processes which aren't up are marked
+UNHEALTHY and listed first.
+
+Usage: deis apps:status [options]
+
+Options:
+  -w --workers=<num>
+    the number of applications to query at once. [default: 4]
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	workers, err := strconv.Atoi(safeGetValue(args, "--workers"))
+	if err != nil || workers < 1 {
+		return fmt.Errorf("%s is not a valid number of workers, must be at least 1", safeGetValue(args, "--workers"))
+	}
+
+	return cmdr.AppsStatus(workers)
 }
 
 func appInfo(argv []string, cmdr cmd.Commander) error {
@@ -138,7 +199,7 @@ Options:
 
 	app := safeGetValue(args, "--app")
 
-	return cmdr.AppInfo(app)
+	return cmdr.AppInfo(app, time.Now())
 }
 
 func appOpen(argv []string, cmdr cmd.Commander) error {
@@ -205,15 +266,36 @@ func appRun(argv []string, cmdr cmd.Commander) error {
 Runs a command inside an ephemeral app container. Default environment is
 /bin/bash.
 
+The command's output is printed once it has finished, and 'deis run' exits with
+the command's exit code. Interactive sessions, such as a shell or a console, are
+not supported: the controller runs commands to completion and can't stream
+input or output while they run.
+
+With --file, a local script is uploaded and executed inside the container instead,
+with any <args> passed to it. Use '--file=-' to read the script from stdin.
+
 Usage: deis apps:run [options] [--] <command>...
+       deis apps:run --file=<file> [-e <var>]... [options] [--] [<args>...]
 
 Arguments:
   <command>
     the shell command to run inside the container.
+  <args>
+    the arguments passed to the script.
 
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
+  -f --file=<file>
+    a local script to run inside the container, or '-' for stdin.
+  -e --env=<var>
+    an environment variable for the script, such as 'DEBUG=1'. Can be given
+    multiple times.
+  -t --timeout=<timeout>
+    how long to wait for the command to finish, such as '90s' or '10m'.
+    Waits indefinitely if not set.
+  --tty
+    run the command interactively. Not supported by the controller yet.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -222,10 +304,29 @@ Options:
 		return err
 	}
 
+	if args["--tty"].(bool) {
+		return errors.New("interactive commands are not supported: the controller runs commands to completion " +
+			"and can't stream input or output, run the command without --tty")
+	}
+
 	app := safeGetValue(args, "--app")
+	file := safeGetValue(args, "--file")
+
+	var timeout time.Duration
+	if timeoutStr := safeGetValue(args, "--timeout"); timeoutStr != "" {
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+			return fmt.Errorf("could not parse timeout: %s", err)
+		}
+	}
+
+	if file != "" {
+		scriptArgs := append(args["<command>"].([]string), args["<args>"].([]string)...)
+		return cmdr.AppRunScript(app, file, scriptArgs, args["--env"].([]string), timeout)
+	}
+
 	command := strings.Join(args["<command>"].([]string), " ")
 
-	return cmdr.AppRun(app, command)
+	return cmdr.AppRun(app, command, timeout)
 }
 
 func appDestroy(argv []string, cmdr cmd.Commander) error {
@@ -280,3 +381,57 @@ Options:
 
 	return cmdr.AppTransfer(app, user)
 }
+
+func appCost(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Estimates what an application costs per month.
+
+The estimate multiplies the number of processes of each type by their memory and CPU
+limits, priced per GiB of memory and per vCPU for every hour of the month. The prices
+are saved once given, so they only need to be passed again when they change. A price
+of 0 makes that resource free.
+
+Usage: deis apps:cost [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+  --memory-price=<price>
+    the price of one GiB of memory per hour, such as 0.005.
+  --cpu-price=<price>
+    the price of one vCPU per hour, such as 0.03.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	memoryPrice, err := parsePrice(args, "--memory-price")
+	if err != nil {
+		return err
+	}
+
+	cpuPrice, err := parsePrice(args, "--cpu-price")
+	if err != nil {
+		return err
+	}
+
+	return cmdr.AppCost(safeGetValue(args, "--app"), memoryPrice, cpuPrice)
+}
+
+// parsePrice parses a price option, returning nil if it was not given.
+func parsePrice(args map[string]interface{}, option string) (*float64, error) {
+	value := safeGetValue(args, option)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be zero or a positive number, got %s", option, value)
+	}
+
+	return &price, nil
+}
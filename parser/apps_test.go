@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/workflow-cli/pkg/testutil"
@@ -16,11 +17,11 @@ func (d FakeDeisCmd) AppCreate(string, string, string, bool) error {
 	return errors.New("apps:create")
 }
 
-func (d FakeDeisCmd) AppsList(int) error {
+func (d FakeDeisCmd) AppsList(int, string, string, time.Time, []string, string, bool) error {
 	return errors.New("apps:list")
 }
 
-func (d FakeDeisCmd) AppInfo(string) error {
+func (d FakeDeisCmd) AppInfo(string, time.Time) error {
 	return errors.New("apps:info")
 }
 
@@ -32,10 +33,14 @@ func (d FakeDeisCmd) AppLogs(string, int) error {
 	return errors.New("apps:logs")
 }
 
-func (d FakeDeisCmd) AppRun(string, string) error {
+func (d FakeDeisCmd) AppRun(string, string, time.Duration) error {
 	return errors.New("apps:run")
 }
 
+func (d FakeDeisCmd) AppRunScript(string, string, []string, []string, time.Duration) error {
+	return errors.New("apps:run --file")
+}
+
 func (d FakeDeisCmd) AppDestroy(string, string) error {
 	return errors.New("apps:destroy")
 }
@@ -44,6 +49,14 @@ func (d FakeDeisCmd) AppTransfer(string, string) error {
 	return errors.New("apps:transfer")
 }
 
+func (d FakeDeisCmd) AppsStatus(int) error {
+	return errors.New("apps:status")
+}
+
+func (d FakeDeisCmd) AppCost(string, *float64, *float64) error {
+	return errors.New("apps:cost")
+}
+
 func TestApps(t *testing.T) {
 	t.Parallel()
 
@@ -69,6 +82,26 @@ func TestApps(t *testing.T) {
 			args:     []string{"apps:list"},
 			expected: "",
 		},
+		{
+			args:     []string{"apps:list", "--owner=admin", "--name=pay-*", "--tag=env=prod", "--sort=created", "--reverse"},
+			expected: "apps:list",
+		},
+		{
+			args:     []string{"apps:list", "--created-before=yesterday"},
+			expected: "yesterday is not a valid date or age, ex: 2016-01-31, 90d",
+		},
+		{
+			args:     []string{"apps:list", "--created-before=-90d"},
+			expected: "--created-before must be positive, got -90d",
+		},
+		{
+			args:     []string{"apps:status", "--workers=2"},
+			expected: "",
+		},
+		{
+			args:     []string{"apps:status", "--workers=0"},
+			expected: "0 is not a valid number of workers, must be at least 1",
+		},
 		{
 			args:     []string{"apps:info"},
 			expected: "",
@@ -89,6 +122,18 @@ func TestApps(t *testing.T) {
 			args:     []string{"apps:run", "ls"},
 			expected: "",
 		},
+		{
+			args:     []string{"apps:run", "--timeout=30s", "ls"},
+			expected: "",
+		},
+		{
+			args:     []string{"apps:run", "--tty", "python", "manage.py", "shell"},
+			expected: "interactive commands are not supported: the controller runs commands to completion and can't stream input or output, run the command without --tty",
+		},
+		{
+			args:     []string{"apps:run", "--file=fixup.sh", "-e", "DEBUG=1", "--", "--dry-run"},
+			expected: "apps:run --file",
+		},
 		{
 			args:     []string{"apps:destroy"},
 			expected: "",
@@ -97,6 +142,10 @@ func TestApps(t *testing.T) {
 			args:     []string{"apps:transfer", "test-user"},
 			expected: "",
 		},
+		{
+			args:     []string{"apps:cost", "--memory-price=0.005", "--cpu-price=0.03"},
+			expected: "",
+		},
 		{
 			args:     []string{"apps"},
 			expected: "apps:list",
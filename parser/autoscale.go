@@ -69,11 +69,11 @@ Arguments:
   <process-type>
     the process type to add to the application's autoscale settings.
   --min=<min>
-	minimum replicas to keep around
+	minimum replicas to keep around, at least 1
   --max=<max>
-	max replicas to scale up to
+	max replicas to scale up to, no less than --min
   --cpu-percent=<cpu-percent>
-	target CPU utilization
+	target CPU utilization, between 1 and 100 percent of the process type's CPU limit
 
 Options:
   -a --app=<app>
@@ -18,11 +18,16 @@ certs:remove          remove an SSL certificate from an app
 certs:info            get detailed informaton about the certificate
 certs:attach          attach an SSL certificate to a domain
 certs:detach          detach an SSL certificate from a domain
+certs:rotate          replace an SSL certificate on all of its domains
+certs:issue           issue an SSL certificate from an ACME CA such as Let's Encrypt
+certs:check           check for SSL certificates which expired or expire soon
 
 Use 'deis help [command]' to learn more.
 `
 
 	switch argv[0] {
+	case "certs:check":
+		return certsCheck(argv, cmdr)
 	case "certs:list":
 		return certsList(argv, cmdr)
 	case "certs:add":
@@ -35,6 +40,10 @@ Use 'deis help [command]' to learn more.
 		return certAttach(argv, cmdr)
 	case "certs:detach":
 		return certDetach(argv, cmdr)
+	case "certs:rotate":
+		return certRotate(argv, cmdr)
+	case "certs:issue":
+		return certIssue(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -78,6 +87,10 @@ func certAdd(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Binds a certificate/key pair to an application.
 
+The certificate and key are checked before they are uploaded: the key must match the
+certificate, the certificate and its intermediates must be valid today, and the chain
+must list each certificate before its issuer. A summary of the certificate is printed.
+
 Usage: deis certs:add <name> <cert> <key> [options]
 
 Arguments:
@@ -89,6 +102,9 @@ Arguments:
     The private key of the SSL certificate.
 
 Options:
+  --force
+    add the certificate even if it fails the checks, such as a certificate
+    which is not valid yet. The problems found are printed as warnings.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -100,7 +116,7 @@ Options:
 	cert := args["<cert>"].(string)
 	key := args["<key>"].(string)
 
-	return cmdr.CertAdd(cert, key, name)
+	return cmdr.CertAdd(cert, key, name, args["--force"].(bool), time.Now())
 }
 
 func certRemove(argv []string, cmdr cmd.Commander) error {
@@ -147,9 +163,11 @@ Options:
 
 func certAttach(argv []string, cmdr cmd.Commander) error {
 	usage := `
-attach a certificate to a domain.
+attach a certificate to a domain, or with --auto to every domain of every app which
+is covered by the certificate's common name or SubjectAltName.
 
 Usage: deis certs:attach <name> <domain> [options]
+       deis certs:attach --auto <name> [options]
 
 Arguments:
   <name>
@@ -158,6 +176,10 @@ Arguments:
     common name of the domain to attach to (needs to already be in the system)
 
 Options:
+  --auto
+    attach the certificate to all matching domains, after previewing them.
+  --yes
+    force "yes" when prompted.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -166,6 +188,10 @@ Options:
 	}
 
 	name := safeGetValue(args, "<name>")
+	if args["--auto"].(bool) {
+		return cmdr.CertAttachAuto(name, args["--yes"].(bool))
+	}
+
 	domain := safeGetValue(args, "<domain>")
 	return cmdr.CertAttach(name, domain)
 }
@@ -194,3 +220,123 @@ Options:
 	domain := safeGetValue(args, "<domain>")
 	return cmdr.CertDetach(name, domain)
 }
+
+func certsCheck(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Checks for SSL certificates which expired or expire soon.
+
+The first line of output summarizes the check, followed by the certificates that need
+attention. The exit code follows the conventions of monitoring plugins such as Nagios:
+0 when no certificate expires soon, 1 when some expire within the given duration,
+2 when some have already expired and 3 when the check could not be done.
+
+Usage: deis certs:check [options]
+
+Options:
+  --within=<duration>
+    how soon a certificate must expire to be reported, such as 30d or 72h. [default: 30d]
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+	if err != nil {
+		return err
+	}
+
+	// an invalid duration is reported like any other failure to check, as UNKNOWN.
+	within, err := parseDuration(safeGetValue(args, "--within"))
+	if err != nil {
+		cmdr.Printf("UNKNOWN: %v\n", err)
+		return cmd.ExitCodeError{Code: 3}
+	}
+
+	return cmdr.CertsCheck(within, time.Now())
+}
+
+func certRotate(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Replaces a certificate with a new one. The new certificate is added and checked like
+with certs:add, each domain of the old certificate is moved to it, and the old
+certificate is removed. If a step fails, the domains are moved back to the old
+certificate and the new one is removed.
+
+Usage: deis certs:rotate <old> --cert=<cert> --key=<key> [options]
+
+Arguments:
+  <old>
+    name of the certificate to replace.
+
+Options:
+  --cert=<cert>
+    The public key of the new SSL certificate.
+  --key=<key>
+    The private key of the new SSL certificate.
+  --name=<name>
+    name of the new certificate, defaults to the old name followed by today's date.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+	if err != nil {
+		return err
+	}
+
+	old := safeGetValue(args, "<old>")
+	cert := safeGetValue(args, "--cert")
+	key := safeGetValue(args, "--key")
+	name := safeGetValue(args, "--name")
+
+	return cmdr.CertRotate(old, cert, key, name, time.Now())
+}
+
+func certIssue(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Issues a certificate for one or more domains from an ACME certificate authority such as
+Let's Encrypt, then adds it and attaches it to each domain. The domains need to be added
+to an app with domains:add first.
+
+The certificate authority checks that you control each domain with a challenge:
+
+  http-01  the authority fetches a token over HTTP from the domain, which this command
+           serves on --listen. The domain must route port 80 to this machine.
+  dns-01   this command prints a TXT record to create under _acme-challenge.<domain>,
+           and waits for it to resolve.
+
+An account is registered with the authority on first use and saved next to the client
+settings. Registering asks you to agree to the authority's terms of service, unless
+--agree-tos is given.
+
+Usage: deis certs:issue --domain=<domain>... [options]
+
+Options:
+  --domain=<domain>
+    a domain to issue the certificate for, the first one is its common name.
+  -n --name=<name>
+    name of the certificate, defaults to the first domain with dots replaced by dashes.
+  --directory=<url>
+    the ACME directory of the certificate authority.
+    [default: https://acme-v02.api.letsencrypt.org/directory]
+  --challenge=<type>
+    the challenge type to use, http-01 or dns-01. [default: http-01]
+  --email=<email>
+    contact address for the account, used for expiry notices.
+  --agree-tos
+    agree to the terms of service of the certificate authority without being prompted.
+  --listen=<address>
+    address to serve http-01 challenges on. [default: :80]
+  --timeout=<timeout>
+    how long to wait for the certificate to be issued. [default: 5m]
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := parseDuration(safeGetValue(args, "--timeout"))
+	if err != nil {
+		return err
+	}
+
+	return cmdr.CertIssue(args["--domain"].([]string), safeGetValue(args, "--name"), safeGetValue(args, "--directory"),
+		safeGetValue(args, "--challenge"), safeGetValue(args, "--email"), safeGetValue(args, "--listen"),
+		args["--agree-tos"].(bool), timeout, time.Now())
+}
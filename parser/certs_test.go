@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/arschles/assert"
+	"github.com/deis/workflow-cli/cmd"
 	"github.com/deis/workflow-cli/pkg/testutil"
 )
 
@@ -17,7 +18,7 @@ func (d FakeDeisCmd) CertsList(int, time.Time) error {
 	return errors.New("certs:list")
 }
 
-func (d FakeDeisCmd) CertAdd(string, string, string) error {
+func (d FakeDeisCmd) CertAdd(string, string, string, bool, time.Time) error {
 	return errors.New("certs:add")
 }
 
@@ -29,10 +30,26 @@ func (d FakeDeisCmd) CertInfo(string) error {
 	return errors.New("certs:info")
 }
 
+func (d FakeDeisCmd) CertsCheck(time.Duration, time.Time) error {
+	return errors.New("certs:check")
+}
+
 func (d FakeDeisCmd) CertAttach(string, string) error {
 	return errors.New("certs:attach")
 }
 
+func (d FakeDeisCmd) CertAttachAuto(string, bool) error {
+	return errors.New("certs:attach --auto")
+}
+
+func (d FakeDeisCmd) CertRotate(string, string, string, string, time.Time) error {
+	return errors.New("certs:rotate")
+}
+
+func (d FakeDeisCmd) CertIssue([]string, string, string, string, string, string, bool, time.Duration, time.Time) error {
+	return errors.New("certs:issue")
+}
+
 func (d FakeDeisCmd) CertDetach(string, string) error {
 	return errors.New("certs:detach")
 }
@@ -62,6 +79,10 @@ func TestCerts(t *testing.T) {
 			args:     []string{"certs:add", "name", "cert", "key"},
 			expected: "",
 		},
+		{
+			args:     []string{"certs:add", "name", "cert", "key", "--force"},
+			expected: "certs:add",
+		},
 		{
 			args:     []string{"certs:remove", "name"},
 			expected: "",
@@ -70,14 +91,34 @@ func TestCerts(t *testing.T) {
 			args:     []string{"certs:info", "name"},
 			expected: "",
 		},
+		{
+			args:     []string{"certs:check", "--within=14d"},
+			expected: "",
+		},
 		{
 			args:     []string{"certs:attach", "name", "example.com"},
 			expected: "",
 		},
+		{
+			args:     []string{"certs:attach", "--auto", "name", "--yes"},
+			expected: "certs:attach --auto",
+		},
 		{
 			args:     []string{"certs:detach", "name", "example.com"},
 			expected: "",
 		},
+		{
+			args:     []string{"certs:rotate", "name", "--cert=new.pem", "--key=new.key"},
+			expected: "",
+		},
+		{
+			args:     []string{"certs:issue", "--domain=example.com", "--domain=www.example.com", "--challenge=dns-01"},
+			expected: "",
+		},
+		{
+			args:     []string{"certs:issue", "--domain=example.com", "--agree-tos"},
+			expected: "certs:issue",
+		},
 		{
 			args:     []string{"certs"},
 			expected: "certs:list",
@@ -96,4 +137,8 @@ func TestCerts(t *testing.T) {
 		err = Certs(c.args, cmdr)
 		assert.Err(t, errors.New(expected), err)
 	}
+
+	// an invalid duration is an UNKNOWN result, not a usage error.
+	err = Certs([]string{"certs:check", "--within=soon"}, cmdr)
+	assert.Equal(t, err, cmd.ExitCodeError{Code: 3}, "error")
 }
@@ -13,6 +13,8 @@ Valid commands for domains:
 domains:add           bind a domain to an application
 domains:list          list domains bound to an application
 domains:remove        unbind a domain from an application
+domains:sync          bind the domains listed in a file to an application
+domains:check         check that the DNS of an application's domains points at the router
 
 Use 'deis help [command]' to learn more.
 `
@@ -24,6 +26,10 @@ Use 'deis help [command]' to learn more.
 		return domainsList(argv, cmdr)
 	case "domains:remove":
 		return domainsRemove(argv, cmdr)
+	case "domains:sync":
+		return domainsSync(argv, cmdr)
+	case "domains:check":
+		return domainsCheck(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -121,3 +127,59 @@ Options:
 
 	return cmdr.DomainsRemove(app, domain)
 }
+
+func domainsSync(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Binds the domains listed in a file to an application.
+
+The file lists one domain per line, such as 'www.example.com' or '*.example.com'. Blank
+lines and lines starting with '#' are ignored. Every domain is checked before anything
+is changed, and the result is reported for each domain.
+
+Usage: deis domains:sync --file=<file> [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+  -f --file=<file>
+    the file listing the domains.
+  --prune
+    also unbind the domains that are not listed in the file.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	app := safeGetValue(args, "--app")
+	file := safeGetValue(args, "--file")
+
+	return cmdr.DomainsSync(app, file, args["--prune"].(bool))
+}
+
+func domainsCheck(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Checks that the DNS records of an application's domains point at the router.
+
+Each domain is resolved and compared with the router serving the controller. A domain
+is OK when it is a CNAME to a name under the router's domain, or when it resolves to
+one of the router's addresses. Domains resolving elsewhere are misconfigured, and
+domains that don't resolve at all are dangling.
+
+Usage: deis domains:check [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmdr.DomainsCheck(safeGetValue(args, "--app"))
+}
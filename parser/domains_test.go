@@ -24,6 +24,14 @@ func (d FakeDeisCmd) DomainsRemove(string, string) error {
 	return errors.New("domains:remove")
 }
 
+func (d FakeDeisCmd) DomainsSync(string, string, bool) error {
+	return errors.New("domains:sync")
+}
+
+func (d FakeDeisCmd) DomainsCheck(string) error {
+	return errors.New("domains:check")
+}
+
 func TestDomains(t *testing.T) {
 	t.Parallel()
 
@@ -53,6 +61,14 @@ func TestDomains(t *testing.T) {
 			args:     []string{"domains:remove", "example.com"},
 			expected: "",
 		},
+		{
+			args:     []string{"domains:sync", "-f", "domains.txt", "--prune"},
+			expected: "",
+		},
+		{
+			args:     []string{"domains:check"},
+			expected: "",
+		},
 		{
 			args:     []string{"domains"},
 			expected: "domains:list",
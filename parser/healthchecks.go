@@ -25,6 +25,8 @@ Valid commands for healthchecks:
 healthchecks:list        list healthchecks for an app
 healthchecks:set         set healthchecks for an app
 healthchecks:unset       unset healthchecks for an app
+healthchecks:copy        copy healthchecks from one process type to others
+healthchecks:test        try out a healthcheck probe locally
 
 Use 'deis help [command]' to learn more.
 `
@@ -36,6 +38,10 @@ Use 'deis help [command]' to learn more.
 		return healthchecksSet(argv, cmdr)
 	case "healthchecks:unset":
 		return healthchecksUnset(argv, cmdr)
+	case "healthchecks:copy":
+		return healthchecksCopy(argv, cmdr)
+	case "healthchecks:test":
+		return healthchecksTest(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -62,6 +68,8 @@ Options:
     the uniquely identifiable name of the application.
   --type=<type>
     the procType for which the health check needs to be listed.
+  --yaml
+    print the healthchecks as YAML, keyed by procType, so they can be kept and reused.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -73,6 +81,10 @@ Options:
 	app := safeGetValue(args, "--app")
 	procType := safeGetValue(args, "--type")
 
+	if args["--yaml"].(bool) {
+		return cmdr.HealthchecksExport(app, procType)
+	}
+
 	return cmdr.HealthchecksList(app, procType)
 }
 
@@ -106,7 +118,11 @@ probes accept a string of arguments to be run inside the Container.
 considered healthy if the check can establish a connection. 'tcpSocket' probes accept a
 port number to perform the socket connection on the Container.
 
+With --file, the probes of every process type are read from a YAML file in the format
+printed by 'deis healthchecks:list --yaml' and applied at once.
+
 Usage: deis healthchecks:set <health-type> <probe-type> [options] [--] <args>...
+       deis healthchecks:set --file=<file> [options]
 
 Arguments:
   <health-type>
@@ -120,6 +136,8 @@ Arguments:
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
+  -f --file=<file>
+    a YAML file with the probes to set, keyed by procType.
   -p --path=<path>
     the relative URL path for 'httpGet' probes. [default: /]
   --type=<type>
@@ -145,24 +163,17 @@ Options:
 	}
 
 	app := safeGetValue(args, "--app")
-	path := safeGetValue(args, "--path")
-	procType := safeGetValue(args, "--type")
-	initialDelayTimeout := safeGetInt(args, "--initial-delay-timeout")
-	timeoutSeconds := safeGetInt(args, "--timeout-seconds")
-	periodSeconds := safeGetInt(args, "--period-seconds")
-	successThreshold := safeGetInt(args, "--success-threshold")
-	failureThreshold := safeGetInt(args, "--failure-threshold")
-	headers := []string{}
-	if args["--headers"] != nil {
-		headers = args["--headers"].([]string)
+
+	if file := safeGetValue(args, "--file"); file != "" {
+		return cmdr.HealthchecksSetFile(app, file)
 	}
+
+	procType := safeGetValue(args, "--type")
 	if procType == "" {
 		procType = defaultProcType
 	}
 
 	healthcheckType := args["<health-type>"].(string)
-	probeType := args["<probe-type>"].(string)
-	probeArgs := args["<args>"].([]string)
 
 	if healthcheckType != "liveness" && healthcheckType != "readiness" {
 		return fmt.Errorf("Invalid healthcheck type. Must be one of: \"liveness\", \"readiness\"")
@@ -172,23 +183,123 @@ Options:
 	// add that to the end of the healthcheck type so the controller sees the right probe type
 	healthcheckType += "Probe"
 
+	probe, err := parseProbe(args)
+	if err != nil {
+		return err
+	}
+
+	return cmdr.HealthchecksSet(app, healthcheckType, procType, probe)
+}
+
+func healthchecksCopy(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Copies the healthchecks of one process type to other process types.
+
+The probes of the target process types are replaced, so a probe the source process type
+doesn't have is removed from them.
+
+Usage: deis healthchecks:copy --from=<type> --to=<types> [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+  --from=<type>
+    the procType to copy the healthchecks from.
+  --to=<types>
+    the procTypes to copy the healthchecks to, separated by commas.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	app := safeGetValue(args, "--app")
+	from := safeGetValue(args, "--from")
+	to := strings.Split(safeGetValue(args, "--to"), ",")
+
+	return cmdr.HealthchecksCopy(app, from, to)
+}
+
+func healthchecksTest(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Tries out a healthcheck probe against a local host before it is set on an application.
+
+The probe is run the same way the scheduler runs it: every period, until it passes
+success-threshold times in a row or fails failure-threshold times in a row. Each attempt
+is reported along with how long it took. 'exec' probes run the command on this machine.
+
+Usage: deis healthchecks:test <probe-type> [options] [--] <args>...
+
+Arguments:
+  <probe-type>
+    the healthcheck probe type, such as 'httpGet', 'exec' or 'tcpSocket'.
+  <args>
+    The arguments required for the healthcheck probe. 'exec', accepts a list of arguments;
+    'httpGet' and 'tcpSocket' accept a port number.
+
+Options:
+  --host=<host>
+    the host to run 'httpGet' and 'tcpSocket' probes against. [default: localhost]
+  -p --path=<path>
+    the relative URL path for 'httpGet' probes. [default: /]
+  --header=<header>...
+    the HTTP headers to send for 'httpGet' probes, separated by commas.
+  --initial-delay-timeout=<initial-delay-timeout>
+    the initial delay timeout for the probe [default: 0]
+  --timeout-seconds=<timeout-seconds>
+    the number of seconds after which the probe times out [default: 50]
+  --period-seconds=<period-seconds>
+    how often (in seconds) to perform the probe [default: 10]
+  --success-threshold=<success-threshold>
+    minimum consecutive successes for the probe to be considered successful after having failed [default: 1]
+  --failure-threshold=<failure-threshold>
+    minimum consecutive successes for the probe to be considered failed after having succeeded [default: 3]
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	probe, err := parseProbe(args)
+	if err != nil {
+		return err
+	}
+
+	return cmdr.HealthchecksTest(safeGetValue(args, "--host"), probe)
+}
+
+// parseProbe builds a healthcheck probe from the probe options shared by healthchecks:set
+// and healthchecks:test.
+func parseProbe(args map[string]interface{}) (*api.Healthcheck, error) {
+	path := safeGetValue(args, "--path")
+	probeType := args["<probe-type>"].(string)
+	probeArgs := args["<args>"].([]string)
+	var headers []string
+	if header := safeGetValue(args, "--header"); header != "" {
+		headers = strings.Split(header, ",")
+	}
+
 	probe := &api.Healthcheck{
-		InitialDelaySeconds: initialDelayTimeout,
-		TimeoutSeconds:      timeoutSeconds,
-		PeriodSeconds:       periodSeconds,
-		SuccessThreshold:    successThreshold,
-		FailureThreshold:    failureThreshold,
+		InitialDelaySeconds: safeGetInt(args, "--initial-delay-timeout"),
+		TimeoutSeconds:      safeGetInt(args, "--timeout-seconds"),
+		PeriodSeconds:       safeGetInt(args, "--period-seconds"),
+		SuccessThreshold:    safeGetInt(args, "--success-threshold"),
+		FailureThreshold:    safeGetInt(args, "--failure-threshold"),
 	}
 
 	switch probeType {
 	case "httpGet":
 		parsedHeaders, err := parseHeaders(headers)
 		if err != nil {
-			return fmt.Errorf("could not parse headers: %s", err)
+			return nil, fmt.Errorf("could not parse headers: %s", err)
 		}
 		port, err := strconv.Atoi(probeArgs[0])
 		if err != nil {
-			return fmt.Errorf("could not parse port: %s", err)
+			return nil, fmt.Errorf("could not parse port: %s", err)
 		}
 		probe.HTTPGet = &api.HTTPGetProbe{
 			Path:        path,
@@ -202,16 +313,16 @@ Options:
 	case "tcpSocket":
 		port, err := strconv.Atoi(probeArgs[0])
 		if err != nil {
-			return fmt.Errorf("could not parse port: %s", err)
+			return nil, fmt.Errorf("could not parse port: %s", err)
 		}
 		probe.TCPSocket = &api.TCPSocketProbe{
 			Port: port,
 		}
 	default:
-		return fmt.Errorf("Invalid probe type. Must be one of: \"httpGet\", \"exec\"")
+		return nil, fmt.Errorf("Invalid probe type. Must be one of: \"httpGet\", \"exec\"")
 	}
 
-	return cmdr.HealthchecksSet(app, healthcheckType, procType, probe)
+	return probe, nil
 }
 
 func healthchecksUnset(argv []string, cmdr cmd.Commander) error {
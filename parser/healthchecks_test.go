@@ -17,6 +17,10 @@ func (d FakeDeisCmd) HealthchecksList(string, string) error {
 	return errors.New("healthchecks:list")
 }
 
+func (d FakeDeisCmd) HealthchecksExport(string, string) error {
+	return errors.New("healthchecks:list --yaml")
+}
+
 func (d FakeDeisCmd) HealthchecksSet(string, string, string, *api.Healthcheck) error {
 	return errors.New("healthchecks:set")
 }
@@ -25,6 +29,18 @@ func (d FakeDeisCmd) HealthchecksUnset(string, string, []string) error {
 	return errors.New("healthchecks:unset")
 }
 
+func (d FakeDeisCmd) HealthchecksSetFile(string, string) error {
+	return errors.New("healthchecks:set --file")
+}
+
+func (d FakeDeisCmd) HealthchecksCopy(string, string, []string) error {
+	return errors.New("healthchecks:copy")
+}
+
+func (d FakeDeisCmd) HealthchecksTest(string, *api.Healthcheck) error {
+	return errors.New("healthchecks:test")
+}
+
 func TestHealthchecks(t *testing.T) {
 	t.Parallel()
 
@@ -46,6 +62,10 @@ func TestHealthchecks(t *testing.T) {
 			args:     []string{"healthchecks:list"},
 			expected: "",
 		},
+		{
+			args:     []string{"healthchecks:list", "--type=web", "--yaml"},
+			expected: "healthchecks:list --yaml",
+		},
 		{
 			args:     []string{"healthchecks:set", "liveness", "httpGet", "80"},
 			expected: "",
@@ -62,10 +82,26 @@ func TestHealthchecks(t *testing.T) {
 			args:     []string{"healthchecks:set", "liveness", "tcpSocket", "80"},
 			expected: "",
 		},
+		{
+			args:     []string{"healthchecks:set", "--file=healthchecks.yml"},
+			expected: "healthchecks:set --file",
+		},
 		{
 			args:     []string{"healthchecks:unset", "liveness"},
 			expected: "",
 		},
+		{
+			args:     []string{"healthchecks:copy", "--from=web", "--to=worker,api"},
+			expected: "",
+		},
+		{
+			args:     []string{"healthchecks:test", "httpGet", "8080", "--path=/healthz"},
+			expected: "",
+		},
+		{
+			args:     []string{"healthchecks:test", "exec", "--", "cat", "/tmp/ready"},
+			expected: "",
+		},
 		{
 			args:     []string{"healthchecks"},
 			expected: "healthchecks:list",
@@ -85,3 +121,41 @@ func TestHealthchecks(t *testing.T) {
 		assert.Err(t, errors.New(expected), err)
 	}
 }
+
+func TestParseProbe(t *testing.T) {
+	t.Parallel()
+
+	args := map[string]interface{}{
+		"<probe-type>":            "httpGet",
+		"<args>":                  []string{"8080"},
+		"--path":                  "/healthz",
+		"--header":                "X-Probe: deis,Accept:text/plain",
+		"--initial-delay-timeout": "5",
+		"--timeout-seconds":       "1",
+		"--period-seconds":        "10",
+		"--success-threshold":     "1",
+		"--failure-threshold":     "3",
+	}
+
+	probe, err := parseProbe(args)
+	assert.NoErr(t, err)
+	assert.Equal(t, probe, &api.Healthcheck{
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+		HTTPGet: &api.HTTPGetProbe{
+			Path: "/healthz",
+			Port: 8080,
+			HTTPHeaders: []*api.KVPair{
+				{Key: "X-Probe", Value: "deis"},
+				{Key: "Accept", Value: "text/plain"},
+			},
+		},
+	}, "probe")
+
+	args["<probe-type>"] = "grpc"
+	_, err = parseProbe(args)
+	assert.Equal(t, err.Error(), "Invalid probe type. Must be one of: \"httpGet\", \"exec\"", "error")
+}
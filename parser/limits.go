@@ -80,7 +80,8 @@ Arguments:
 
     With --memory, units are represented in Bytes (B), Kilobytes (K), Megabytes
     (M), or Gigabytes (G). For example, 'deis limit:set cmd=1G' will restrict all
-    "cmd" processes to a maximum of 1 Gigabyte of memory each.
+    "cmd" processes to a maximum of 1 Gigabyte of memory each. Kubernetes style
+    units such as 512Mi or 1Gi are also accepted, and mean the same as 512M or 1G.
 
     With --cpu, units are represented in the number of CPUs. For example,
     'deis limit:set --cpu cmd=1' will restrict all "cmd" processes to a
@@ -88,6 +89,10 @@ Arguments:
     number of CPU shares the pod can use. For example, 'deis limits:set --cpu cmd=500m'
     will restrict all "cmd" processes to half of a CPU.
 
+    A request can be given along with the limit as 'request/limit'. For example,
+    'deis limits:set cmd=256M/512M' reserves 256 Megabytes for each "cmd" process
+    and lets it use up to 512 Megabytes. The request can't be greater than the limit.
+
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
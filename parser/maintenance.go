@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"time"
+
 	"github.com/deis/workflow-cli/cmd"
 	docopt "github.com/docopt/docopt-go"
 )
@@ -10,9 +12,10 @@ func Maintenance(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Valid commands for maintenance:
 
-maintenance:info   view maintenance mode of an application
-maintenance:on     turn on maintenance for an app
-maintenance:off    turn off maintenance for an app
+maintenance:info     view maintenance mode of an application
+maintenance:status   view since when, by whom and until when maintenance is on
+maintenance:on       turn on maintenance for an app
+maintenance:off      turn off maintenance for an app
 
 Use 'deis help [command]' to learn more.
 `
@@ -20,6 +23,8 @@ Use 'deis help [command]' to learn more.
 	switch argv[0] {
 	case "maintenance:info":
 		return maintenanceInfo(argv, cmdr)
+	case "maintenance:status":
+		return maintenanceStatus(argv, cmdr)
 	case "maintenance:on":
 		return maintenanceEnable(argv, cmdr)
 	case "maintenance:off":
@@ -59,15 +64,42 @@ Options:
 	return cmdr.MaintenanceInfo(safeGetValue(args, "--app"))
 }
 
+func maintenanceStatus(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Prints whether maintenance mode is on for an application. If it was turned on from this
+client, also prints since when, by whom and when it is due to be turned off.
+
+Usage: deis maintenance:status [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmdr.MaintenanceStatus(safeGetValue(args, "--app"), time.Now())
+}
+
 func maintenanceEnable(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Enables maintenance mode for an app.
 
+With --for, the command keeps running until the window has passed and then turns
+maintenance mode off again. Interrupting it with Ctrl-C, terminating it or closing its
+terminal turns maintenance mode off straight away.
+
 Usage: deis maintenance:on [options]
 
 Options:
   -a --app=<app>
     the uniquely identifiable name of the application.
+  --for=<duration>
+    how long to keep maintenance mode on, such as 30m or 2h.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -76,7 +108,14 @@ Options:
 		return err
 	}
 
-	return cmdr.MaintenanceEnable(safeGetValue(args, "--app"))
+	var window time.Duration
+	if duration := safeGetValue(args, "--for"); duration != "" {
+		if window, err = parsePositiveDuration("--for", duration); err != nil {
+			return err
+		}
+	}
+
+	return cmdr.MaintenanceEnable(safeGetValue(args, "--app"), window)
 }
 
 func maintenanceDisable(argv []string, cmdr cmd.Commander) error {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/workflow-cli/pkg/testutil"
@@ -16,7 +17,7 @@ func (d FakeDeisCmd) MaintenanceInfo(string) error {
 	return errors.New("maintenance:info")
 }
 
-func (d FakeDeisCmd) MaintenanceEnable(string) error {
+func (d FakeDeisCmd) MaintenanceEnable(string, time.Duration) error {
 	return errors.New("maintenance:on")
 }
 
@@ -24,6 +25,10 @@ func (d FakeDeisCmd) MaintenanceDisable(string) error {
 	return errors.New("maintenance:off")
 }
 
+func (d FakeDeisCmd) MaintenanceStatus(string, time.Time) error {
+	return errors.New("maintenance:status")
+}
+
 func TestMaintenance(t *testing.T) {
 	t.Parallel()
 
@@ -49,6 +54,22 @@ func TestMaintenance(t *testing.T) {
 			args:     []string{"maintenance:on"},
 			expected: "",
 		},
+		{
+			args:     []string{"maintenance:on", "--for=30m"},
+			expected: "",
+		},
+		{
+			args:     []string{"maintenance:on", "--for=0s"},
+			expected: "--for must be positive, got 0s",
+		},
+		{
+			args:     []string{"maintenance:on", "--for=-30m"},
+			expected: "--for must be positive, got -30m",
+		},
+		{
+			args:     []string{"maintenance:status"},
+			expected: "",
+		},
 		{
 			args:     []string{"maintenance:off"},
 			expected: "",
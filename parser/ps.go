@@ -1,6 +1,11 @@
 package parser
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/deis/workflow-cli/cmd"
 	docopt "github.com/docopt/docopt-go"
 )
@@ -10,9 +15,14 @@ func Ps(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Valid commands for processes:
 
-ps:list        list application processes
-ps:restart     restart an application or its process types
-ps:scale       scale processes (e.g. web=4 worker=2)
+ps:list            list application processes
+ps:info            view information about a process
+ps:restart         restart an application or its process types
+ps:scale           scale processes (e.g. web=4 worker=2)
+ps:schedule        list the scale profiles of an application
+ps:schedule:set    create or replace a scale profile
+ps:schedule:unset  remove scale profiles
+ps:apply-profile   scale an application to one of its scale profiles
 
 Use 'deis help [command]' to learn more.
 `
@@ -20,10 +30,20 @@ Use 'deis help [command]' to learn more.
 	switch argv[0] {
 	case "ps:list":
 		return psList(argv, cmdr)
+	case "ps:info":
+		return psInfo(argv, cmdr)
 	case "ps:restart":
 		return psRestart(argv, cmdr)
 	case "ps:scale":
 		return psScale(argv, cmdr)
+	case "ps:schedule":
+		return psSchedule(argv, cmdr)
+	case "ps:schedule:set":
+		return psScheduleSet(argv, cmdr)
+	case "ps:schedule:unset":
+		return psScheduleUnset(argv, cmdr)
+	case "ps:apply-profile":
+		return psApplyProfile(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -43,11 +63,23 @@ func psList(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Lists processes servicing an application.
 
+With --watch, the list is redrawn until interrupted, followed by the processes whose
+state changed since the previous refresh.
+
 Usage: deis ps:list [options]
 
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
+  -w --watch
+    keep refreshing the list of processes.
+  --interval=<interval>
+    how often to refresh the list when watching. [default: 2s]
+  --until-ready
+    watch the list of processes and exit once they are all up, or fail if
+    a process crashes.
+  --timeout=<timeout>
+    with --until-ready, fail if the processes are not all up within this long.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -55,14 +87,62 @@ Options:
 		return err
 	}
 
+	app := safeGetValue(args, "--app")
+
+	if args["--watch"].(bool) || args["--until-ready"].(bool) {
+		interval, err := parsePositiveDuration("interval", safeGetValue(args, "--interval"))
+		if err != nil {
+			return err
+		}
+
+		var timeout time.Duration
+		if value := safeGetValue(args, "--timeout"); value != "" {
+			if timeout, err = parsePositiveDuration("timeout", value); err != nil {
+				return err
+			}
+		}
+
+		return cmdr.PsWatch(app, interval, timeout, args["--until-ready"].(bool), time.Now)
+	}
+
 	// The 1000 is fake for now until API understands limits
-	return cmdr.PsList(safeGetValue(args, "--app"), 1000)
+	return cmdr.PsList(app, 1000, time.Now())
+}
+
+func psInfo(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Prints information about a single process of an application, such as when it
+was started, its release and the limits and healthchecks of its process type.
+
+Usage: deis ps:info <process> [options]
+
+Arguments:
+  <process>
+    the full process name, such as 'app-v2-web-asdfg' or
+    'app-web-2180299075-7na91'.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmdr.PsInfo(safeGetValue(args, "--app"), safeGetValue(args, "<process>"), time.Now())
 }
 
 func psRestart(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Restart an application, a process type or a specific process.
 
+With --rolling, the processes of each type are restarted a batch at a time. Each
+batch must be back up before the next one is restarted, and the restart is aborted
+if a process crashes or the batch does not come up within the timeout.
+
 Usage: deis ps:restart [<type>] [options]
 
 Arguments:
@@ -73,6 +153,14 @@ Arguments:
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
+  --rolling
+    restart the processes in batches instead of all at once.
+  --batch-size=<num>
+    the number of processes to restart at a time when rolling. [default: 1]
+  --interval=<interval>
+    how often to check on a batch when rolling. [default: 2s]
+  --timeout=<timeout>
+    how long to wait for each batch to come up when rolling. [default: 5m]
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -83,6 +171,26 @@ Options:
 
 	apps := safeGetValue(args, "--app")
 	tp := safeGetValue(args, "<type>")
+
+	if args["--rolling"].(bool) {
+		batchSize, err := strconv.Atoi(safeGetValue(args, "--batch-size"))
+		if err != nil || batchSize < 1 {
+			return fmt.Errorf("batch size must be a positive number, got %s", safeGetValue(args, "--batch-size"))
+		}
+
+		interval, err := parsePositiveDuration("interval", safeGetValue(args, "--interval"))
+		if err != nil {
+			return err
+		}
+
+		timeout, err := parsePositiveDuration("timeout", safeGetValue(args, "--timeout"))
+		if err != nil {
+			return err
+		}
+
+		return cmdr.PsRollingRestart(apps, tp, batchSize, interval, timeout)
+	}
+
 	return cmdr.PsRestart(apps, tp)
 }
 
@@ -90,14 +198,64 @@ func psScale(argv []string, cmdr cmd.Commander) error {
 	usage := `
 Scales an application's processes by type.
 
+The same scale can be applied to several applications at once, either by giving a
+comma-separated list of applications or by selecting all applications with a tag.
+
 Usage: deis ps:scale <type>=<num>... [options]
+       deis ps:scale --restore [options]
 
 Arguments:
   <type>
     the process name as defined in your Procfile, such as 'web' or 'worker'.
     Note that Dockerfile apps have a default 'cmd' process type.
   <num>
-    the number of processes.
+    the number of processes. Use 'type+num' or 'type-num' instead to add or remove
+    processes relative to the current number, ex: web+2 worker-1.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application. Separate several
+    applications with commas.
+  --tag=<tag>
+    scale every application with the given tag, such as 'environ=staging'.
+  --save
+    save the current number of processes before scaling, so it can be restored
+    later with --restore.
+  --force
+    with --save, replace the number of processes saved before.
+  --restore
+    scale back to the number of processes saved with --save.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	var apps []string
+	if app := safeGetValue(args, "--app"); app != "" {
+		apps = strings.Split(app, ",")
+	}
+	tag := safeGetValue(args, "--tag")
+
+	if args["--restore"].(bool) {
+		return cmdr.PsRestore(apps, tag)
+	}
+
+	return cmdr.PsScale(apps, tag, args["<type>=<num>"].([]string), args["--save"].(bool),
+		args["--force"].(bool))
+}
+
+func psSchedule(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Lists the scale profiles of an application.
+
+Scale profiles are named sets of process counts, such as 'business-hours' or 'night',
+that can be applied with 'deis ps:apply-profile'. They are stored locally, next to the
+client's settings.
+
+Usage: deis ps:schedule [options]
 
 Options:
   -a --app=<app>
@@ -110,6 +268,90 @@ Options:
 		return err
 	}
 
-	apps := safeGetValue(args, "--app")
-	return cmdr.PsScale(apps, args["<type>=<num>"].([]string))
+	return cmdr.PsScheduleList(safeGetValue(args, "--app"))
+}
+
+func psScheduleSet(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Creates or replaces a scale profile of an application.
+
+Usage: deis ps:schedule:set <profile> <type>=<num>... [options]
+
+Arguments:
+  <profile>
+    the name of the scale profile, such as 'business-hours'.
+  <type>
+    the process name as defined in your Procfile, such as 'web' or 'worker'.
+  <num>
+    the number of processes. Use 'type+num' or 'type-num' instead to add or remove
+    processes relative to the current number when the profile is applied.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	app := safeGetValue(args, "--app")
+	profile := safeGetValue(args, "<profile>")
+
+	return cmdr.PsScheduleSet(app, profile, args["<type>=<num>"].([]string))
+}
+
+func psScheduleUnset(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Removes scale profiles from an application.
+
+Usage: deis ps:schedule:unset <profile>... [options]
+
+Arguments:
+  <profile>
+    the name of the scale profile to remove.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmdr.PsScheduleUnset(safeGetValue(args, "--app"), args["<profile>"].([]string))
+}
+
+func psApplyProfile(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Scales an application to one of its scale profiles.
+
+This is meant to be run on a schedule, for example from cron:
+
+  0 8 * * 1-5 deis ps:apply-profile business-hours -a myapp
+  0 20 * * 1-5 deis ps:apply-profile night -a myapp
+
+Usage: deis ps:apply-profile <profile> [options]
+
+Arguments:
+  <profile>
+    the name of the scale profile to apply.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmdr.PsApplyProfile(safeGetValue(args, "--app"), safeGetValue(args, "<profile>"))
 }
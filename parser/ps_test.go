@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/arschles/assert"
 	"github.com/deis/workflow-cli/pkg/testutil"
@@ -12,18 +13,50 @@ import (
 // Create fake implementations of each method that return the argument
 // we expect to have called the function (as an error to satisfy the interface).
 
-func (d FakeDeisCmd) PsList(string, int) error {
+func (d FakeDeisCmd) PsList(string, int, time.Time) error {
 	return errors.New("ps:list")
 }
 
-func (d FakeDeisCmd) PsScale(string, []string) error {
+func (d FakeDeisCmd) PsWatch(string, time.Duration, time.Duration, bool, func() time.Time) error {
+	return errors.New("ps:list --watch")
+}
+
+func (d FakeDeisCmd) PsInfo(string, string, time.Time) error {
+	return errors.New("ps:info")
+}
+
+func (d FakeDeisCmd) PsScale([]string, string, []string, bool, bool) error {
 	return errors.New("ps:scale")
 }
 
+func (d FakeDeisCmd) PsRestore([]string, string) error {
+	return errors.New("ps:scale --restore")
+}
+
+func (d FakeDeisCmd) PsScheduleList(string) error {
+	return errors.New("ps:schedule")
+}
+
+func (d FakeDeisCmd) PsScheduleSet(string, string, []string) error {
+	return errors.New("ps:schedule:set")
+}
+
+func (d FakeDeisCmd) PsScheduleUnset(string, []string) error {
+	return errors.New("ps:schedule:unset")
+}
+
+func (d FakeDeisCmd) PsApplyProfile(string, string) error {
+	return errors.New("ps:apply-profile")
+}
+
 func (d FakeDeisCmd) PsRestart(string, string) error {
 	return errors.New("ps:restart")
 }
 
+func (d FakeDeisCmd) PsRollingRestart(string, string, int, time.Duration, time.Duration) error {
+	return errors.New("ps:restart --rolling")
+}
+
 func TestPs(t *testing.T) {
 	t.Parallel()
 
@@ -45,14 +78,70 @@ func TestPs(t *testing.T) {
 			args:     []string{"ps:list"},
 			expected: "",
 		},
+		{
+			args:     []string{"ps:list", "--watch"},
+			expected: "ps:list --watch",
+		},
+		{
+			args:     []string{"ps:list", "--until-ready", "--interval=5s"},
+			expected: "ps:list --watch",
+		},
+		{
+			args:     []string{"ps:list", "--until-ready", "--timeout=10m"},
+			expected: "ps:list --watch",
+		},
+		{
+			args:     []string{"ps:list", "--watch", "--interval=0s"},
+			expected: "interval must be positive, got 0s",
+		},
+		{
+			args:     []string{"ps:list", "--until-ready", "--timeout=-1m"},
+			expected: "timeout must be positive, got -1m",
+		},
+		{
+			args:     []string{"ps:info", "foo-web-4084101150-c871y"},
+			expected: "",
+		},
 		{
 			args:     []string{"ps:restart", "web"},
 			expected: "",
 		},
+		{
+			args:     []string{"ps:restart", "web", "--rolling", "--batch-size=2"},
+			expected: "ps:restart --rolling",
+		},
+		{
+			args:     []string{"ps:restart", "web", "--rolling", "--interval=-2s"},
+			expected: "interval must be positive, got -2s",
+		},
+		{
+			args:     []string{"ps:schedule"},
+			expected: "",
+		},
+		{
+			args:     []string{"ps:schedule:set", "night", "web=1", "worker=0"},
+			expected: "",
+		},
+		{
+			args:     []string{"ps:schedule:unset", "night"},
+			expected: "",
+		},
+		{
+			args:     []string{"ps:apply-profile", "night"},
+			expected: "",
+		},
 		{
 			args:     []string{"ps:scale", "web", "5"},
 			expected: "",
 		},
+		{
+			args:     []string{"ps:scale", "web+2", "--app=foo,bar", "--save"},
+			expected: "ps:scale",
+		},
+		{
+			args:     []string{"ps:scale", "--restore", "--tag=environ=staging"},
+			expected: "ps:scale --restore",
+		},
 		{
 			args:     []string{"ps:list"},
 			expected: "",
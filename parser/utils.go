@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/deis/workflow-cli/cmd"
 )
@@ -50,3 +52,55 @@ func printHelp(argv []string, usage string) bool {
 
 	return false
 }
+
+// parseDuration parses a duration such as "12h" or "30d". Days are accepted on top of the
+// units understood by time.ParseDuration.
+func parseDuration(duration string) (time.Duration, error) {
+	if strings.HasSuffix(duration, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(duration, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("%s is not a valid duration, ex: 30d, 12h", duration)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid duration, ex: 30d, 12h", duration)
+	}
+	return d, nil
+}
+
+// parsePositiveDuration parses a duration like parseDuration, rejecting zero and negative
+// durations. name is the option being parsed, for the error message.
+func parsePositiveDuration(name, duration string) (time.Duration, error) {
+	d, err := parseDuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %s: %v", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", name, duration)
+	}
+	return d, nil
+}
+
+// parseDateOrAge parses a date such as "2016-01-31", or an age such as "90d", which is
+// the time that long before now. Ages must be positive. name is the option being parsed,
+// for the error message.
+func parseDateOrAge(name, value string, now time.Time) (time.Time, error) {
+	if _, err := parseDuration(value); err == nil {
+		age, err := parsePositiveDuration(name, value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-age), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%s is not a valid date or age, ex: 2016-01-31, 90d", value)
+}
@@ -1,6 +1,9 @@
 package parser
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestSafeGet(t *testing.T) {
 	t.Parallel()
@@ -72,3 +75,83 @@ func TestPrintHelp(t *testing.T) {
 		t.Error("Expected false")
 	}
 }
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"72h": 72 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+
+	for input, expected := range cases {
+		actual, err := parseDuration(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("Expected %s, Got %s", expected, actual)
+		}
+	}
+
+	for _, input := range []string{"d", "1.5d", "soon"} {
+		if _, err := parseDuration(input); err == nil {
+			t.Errorf("Expected an error parsing %s", input)
+		}
+	}
+}
+
+func TestParsePositiveDuration(t *testing.T) {
+	t.Parallel()
+
+	actual, err := parsePositiveDuration("interval", "2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != 2*time.Second {
+		t.Errorf("Expected 2s, Got %s", actual)
+	}
+
+	cases := map[string]string{
+		"0s":   "interval must be positive, got 0s",
+		"-30m": "interval must be positive, got -30m",
+		"soon": "could not parse interval: soon is not a valid duration, ex: 30d, 12h",
+	}
+
+	for input, expected := range cases {
+		if _, err := parsePositiveDuration("interval", input); err == nil || err.Error() != expected {
+			t.Errorf("Expected %q, Got %v", expected, err)
+		}
+	}
+}
+
+func TestParseDateOrAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2016, time.June, 9, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"90d":                  now.Add(-90 * 24 * time.Hour),
+		"2016-01-31":           time.Date(2016, time.January, 31, 0, 0, 0, 0, time.UTC),
+		"2016-01-31T10:00:00Z": time.Date(2016, time.January, 31, 10, 0, 0, 0, time.UTC),
+	}
+
+	for input, expected := range cases {
+		actual, err := parseDateOrAge("--created-before", input, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !actual.Equal(expected) {
+			t.Errorf("Expected %s, Got %s", expected, actual)
+		}
+	}
+
+	if _, err := parseDateOrAge("--created-before", "31/01/2016", now); err == nil {
+		t.Error("Expected an error parsing 31/01/2016")
+	}
+
+	expected := "--created-before must be positive, got -90d"
+	if _, err := parseDateOrAge("--created-before", "-90d", now); err == nil || err.Error() != expected {
+		t.Errorf("Expected error %s, Got %v", expected, err)
+	}
+}
@@ -13,6 +13,7 @@ Valid commands for whitelist:
 whitelist:add           adds addresses to the application's whitelist
 whitelist:list          list addresses in the application's whitelist
 whitelist:remove        remove addresses from the application's whitelist
+whitelist:sync          make the application's whitelist match the addresses in a file
 
 Use 'deis help [command]' to learn more.
 `
@@ -24,6 +25,8 @@ Use 'deis help [command]' to learn more.
 		return whitelistList(argv, cmdr)
 	case "whitelist:remove":
 		return whitelistRemove(argv, cmdr)
+	case "whitelist:sync":
+		return whitelistSync(argv, cmdr)
 	default:
 		if printHelp(argv, usage) {
 			return nil
@@ -41,7 +44,8 @@ Use 'deis help [command]' to learn more.
 
 func whitelistAdd(argv []string, cmdr cmd.Commander) error {
 	usage := `
-Adds addresses to an application whitelist.
+Adds addresses to an application whitelist. Each address is checked first, and addresses
+already covered by the whitelist, or by each other, are warned about.
 
 Usage: deis whitelist:add <addresses> [options]
 
@@ -114,3 +118,37 @@ Options:
 
 	return cmdr.WhitelistRemove(app, addresses)
 }
+
+func whitelistSync(argv []string, cmdr cmd.Commander) error {
+	usage := `
+Makes an application whitelist match the addresses listed in a file.
+
+The file lists one address per line, using IP or CIDR notation, such as '1.2.3.4' or
+'10.0.0.0/8'. Blank lines and lines starting with '#' are ignored. Every address is
+checked before anything is changed. The changes are previewed and confirmed, then
+missing addresses are added before the addresses not listed in the file are removed,
+and each change is reported. A file listing no addresses is refused without --yes, as it
+would remove every address.
+
+Usage: deis whitelist:sync -f <file> [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+  -f --file=<file>
+    the file listing the addresses.
+  --yes
+    force "yes" when prompted.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	app := safeGetValue(args, "--app")
+	file := safeGetValue(args, "--file")
+
+	return cmdr.WhitelistSync(app, file, args["--yes"].(bool))
+}
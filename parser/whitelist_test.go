@@ -24,6 +24,10 @@ func (d FakeDeisCmd) WhitelistRemove(string, string) error {
 	return errors.New("whitelist:remove")
 }
 
+func (d FakeDeisCmd) WhitelistSync(string, string, bool) error {
+	return errors.New("whitelist:sync")
+}
+
 func TestWhitelist(t *testing.T) {
 	t.Parallel()
 
@@ -53,6 +57,14 @@ func TestWhitelist(t *testing.T) {
 			args:     []string{"whitelist:remove", "1.2.3.4"},
 			expected: "",
 		},
+		{
+			args:     []string{"whitelist:sync", "-f", "cidrs.txt"},
+			expected: "",
+		},
+		{
+			args:     []string{"whitelist:sync", "-f", "cidrs.txt", "--yes"},
+			expected: "",
+		},
 		{
 			args:     []string{"whitelist"},
 			expected: "whitelist:list",
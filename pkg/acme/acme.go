@@ -0,0 +1,465 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Statuses of ACME resources.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusValid      = "valid"
+	StatusInvalid    = "invalid"
+)
+
+// pollInterval is how often pending authorizations and orders are checked.
+var pollInterval = time.Second
+
+// Error is a problem document returned by an ACME server.
+type Error struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Detail     string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Type, e.Detail)
+}
+
+// Directory lists the endpoints of an ACME server.
+type Directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+	Meta       struct {
+		TermsOfService string `json:"termsOfService"`
+	} `json:"meta"`
+}
+
+// Account is an account registered with an ACME server.
+type Account struct {
+	URI     string
+	Contact []string
+}
+
+// Order is a request for a certificate.
+type Order struct {
+	URI         string
+	Status      string   `json:"status"`
+	AuthzURLs   []string `json:"authorizations"`
+	FinalizeURL string   `json:"finalize"`
+	CertURL     string   `json:"certificate"`
+	Error       *Error   `json:"error"`
+}
+
+// Identifier is what an authorization proves control of.
+type Identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Authorization is the proof of control of an identifier an order needs.
+type Authorization struct {
+	URI        string
+	Status     string       `json:"status"`
+	Identifier Identifier   `json:"identifier"`
+	Challenges []*Challenge `json:"challenges"`
+}
+
+// Challenge is a way of proving control of an identifier.
+type Challenge struct {
+	Type   string `json:"type"`
+	URI    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+	Error  *Error `json:"error"`
+}
+
+// Client talks to an ACME server on behalf of the account with Key. AccountURL identifies
+// the account once it is registered.
+type Client struct {
+	Key          *ecdsa.PrivateKey
+	AccountURL   string
+	DirectoryURL string
+	HTTPClient   *http.Client
+
+	mu     sync.Mutex
+	dir    *Directory
+	nonces []string
+}
+
+// Discover fetches the directory of the ACME server.
+func (c *Client) Discover(ctx context.Context) (*Directory, error) {
+	c.mu.Lock()
+	dir := c.dir
+	c.mu.Unlock()
+	if dir != nil {
+		return dir, nil
+	}
+
+	req, err := http.NewRequest("GET", c.DirectoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	dir = &Directory{}
+	if err = json.NewDecoder(res.Body).Decode(dir); err != nil {
+		return nil, fmt.Errorf("acme: invalid directory: %v", err)
+	}
+	if dir.NewNonce == "" || dir.NewAccount == "" || dir.NewOrder == "" {
+		return nil, fmt.Errorf("acme: %s is not an RFC 8555 directory", c.DirectoryURL)
+	}
+
+	c.mu.Lock()
+	c.dir = dir
+	c.mu.Unlock()
+	return dir, nil
+}
+
+// Register creates an account for the client's key. If the server has terms of service,
+// agree is called with their URL and the account is only created if it returns true.
+func (c *Client) Register(ctx context.Context, account *Account, agree func(tos string) bool) (*Account, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Contact     []string `json:"contact,omitempty"`
+		TermsAgreed bool     `json:"termsOfServiceAgreed,omitempty"`
+	}{Contact: account.Contact}
+	if tos := dir.Meta.TermsOfService; tos != "" {
+		if !agree(tos) {
+			return nil, fmt.Errorf("acme: the terms of service at %s were not agreed to", tos)
+		}
+		req.TermsAgreed = true
+	}
+
+	res, err := c.post(ctx, dir.NewAccount, req, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	c.AccountURL = res.Header.Get("Location")
+	return &Account{URI: c.AccountURL, Contact: account.Contact}, nil
+}
+
+// AuthorizeOrder orders a certificate for domains.
+func (c *Client) AuthorizeOrder(ctx context.Context, domains []string) (*Order, error) {
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Identifiers []Identifier `json:"identifiers"`
+	}{}
+	for _, domain := range domains {
+		req.Identifiers = append(req.Identifiers, Identifier{Type: "dns", Value: domain})
+	}
+
+	res, err := c.post(ctx, dir.NewOrder, req, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseOrder(res, res.Header.Get("Location"))
+}
+
+// GetAuthorization fetches the authorization at url.
+func (c *Client) GetAuthorization(ctx context.Context, url string) (*Authorization, error) {
+	res, err := c.post(ctx, url, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	authz := &Authorization{URI: url}
+	if err = json.NewDecoder(res.Body).Decode(authz); err != nil {
+		return nil, fmt.Errorf("acme: invalid authorization: %v", err)
+	}
+	return authz, nil
+}
+
+// Accept tells the server the challenge is ready to be validated.
+func (c *Client) Accept(ctx context.Context, challenge *Challenge) (*Challenge, error) {
+	res, err := c.post(ctx, challenge.URI, struct{}{}, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	accepted := &Challenge{}
+	if err = json.NewDecoder(res.Body).Decode(accepted); err != nil {
+		return nil, fmt.Errorf("acme: invalid challenge: %v", err)
+	}
+	return accepted, nil
+}
+
+// WaitAuthorization polls the authorization at url until it is valid or invalid.
+func (c *Client) WaitAuthorization(ctx context.Context, url string) (*Authorization, error) {
+	for {
+		authz, err := c.GetAuthorization(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+
+		switch authz.Status {
+		case StatusValid:
+			return authz, nil
+		case StatusInvalid:
+			for _, challenge := range authz.Challenges {
+				if challenge.Error != nil {
+					return nil, fmt.Errorf("acme: authorization for %s is invalid: %s", authz.Identifier.Value,
+						challenge.Error.Detail)
+				}
+			}
+			return nil, fmt.Errorf("acme: authorization for %s is invalid", authz.Identifier.Value)
+		}
+
+		if err = wait(ctx, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WaitOrder polls the order at url until it is ready to be finalized, or valid.
+func (c *Client) WaitOrder(ctx context.Context, url string) (*Order, error) {
+	for {
+		res, err := c.post(ctx, url, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		order, err := responseOrder(res, url)
+		if err != nil {
+			return nil, err
+		}
+
+		switch order.Status {
+		case StatusReady, StatusValid:
+			return order, nil
+		case StatusInvalid:
+			if order.Error != nil {
+				return nil, fmt.Errorf("acme: order is invalid: %s", order.Error.Detail)
+			}
+			return nil, errors.New("acme: order is invalid")
+		}
+
+		if err = wait(ctx, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CreateOrderCert finalizes the ready order with a DER encoded certificate signing request,
+// waits for the certificate to be issued and returns its chain, DER encoded.
+func (c *Client) CreateOrderCert(ctx context.Context, order *Order, csr []byte) ([][]byte, error) {
+	req := struct {
+		CSR string `json:"csr"`
+	}{base64.RawURLEncoding.EncodeToString(csr)}
+
+	res, err := c.post(ctx, order.FinalizeURL, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	if order, err = responseOrder(res, order.URI); err != nil {
+		return nil, err
+	}
+
+	if order.Status != StatusValid {
+		if order, err = c.WaitOrder(ctx, order.URI); err != nil {
+			return nil, err
+		}
+		if order.Status != StatusValid {
+			return nil, fmt.Errorf("acme: order is %s after finalizing it", order.Status)
+		}
+	}
+
+	if res, err = c.post(ctx, order.CertURL, nil, http.StatusOK); err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	contents, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain [][]byte
+	for {
+		var block *pem.Block
+		if block, contents = pem.Decode(contents); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("acme: the issued certificate chain is empty")
+	}
+
+	return chain, nil
+}
+
+// HTTP01ChallengePath returns the path an http-01 challenge response is served on.
+func (c *Client) HTTP01ChallengePath(token string) string {
+	return "/.well-known/acme-challenge/" + token
+}
+
+// HTTP01ChallengeResponse returns the response to serve for an http-01 challenge.
+func (c *Client) HTTP01ChallengeResponse(token string) (string, error) {
+	return token + "." + thumbprint(&c.Key.PublicKey), nil
+}
+
+// DNS01ChallengeRecord returns the TXT record value to create for a dns-01 challenge.
+func (c *Client) DNS01ChallengeRecord(token string) (string, error) {
+	digest := sha256.Sum256([]byte(token + "." + thumbprint(&c.Key.PublicKey)))
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// post sends a signed request to url, expecting one of the given statuses. A nil payload
+// sends a POST-as-GET request. A rejected nonce is retried once with a fresh one.
+func (c *Client) post(ctx context.Context, url string, payload interface{}, statuses ...int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := signJWS(c.Key, c.AccountURL, nonce, url, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+
+		res, err := c.do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, status := range statuses {
+			if res.StatusCode == status {
+				return res, nil
+			}
+		}
+
+		problem := responseError(res)
+		if problem.Type == "urn:ietf:params:acme:error:badNonce" && attempt == 0 {
+			continue
+		}
+		return nil, problem
+	}
+}
+
+// nonce returns a nonce saved from an earlier response, or fetches a new one.
+func (c *Client) nonce(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if len(c.nonces) > 0 {
+		nonce := c.nonces[len(c.nonces)-1]
+		c.nonces = c.nonces[:len(c.nonces)-1]
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+
+	dir, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("HEAD", dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.nonces) == 0 {
+		return "", errors.New("acme: the server returned no nonce")
+	}
+	nonce := c.nonces[len(c.nonces)-1]
+	c.nonces = c.nonces[:len(c.nonces)-1]
+	return nonce, nil
+}
+
+// do sends a request, saving the nonce of the response.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce := res.Header.Get("Replay-Nonce"); nonce != "" {
+		c.mu.Lock()
+		c.nonces = append(c.nonces, nonce)
+		c.mu.Unlock()
+	}
+	return res, nil
+}
+
+// responseOrder decodes an order from a response, and closes it.
+func responseOrder(res *http.Response, url string) (*Order, error) {
+	defer res.Body.Close()
+
+	order := &Order{URI: url}
+	if err := json.NewDecoder(res.Body).Decode(order); err != nil {
+		return nil, fmt.Errorf("acme: invalid order: %v", err)
+	}
+	return order, nil
+}
+
+// responseError decodes the problem document of a failed response, and closes it.
+func responseError(res *http.Response) *Error {
+	defer res.Body.Close()
+
+	problem := &Error{StatusCode: res.StatusCode}
+	if err := json.NewDecoder(res.Body).Decode(problem); err != nil {
+		problem.Detail = http.StatusText(res.StatusCode)
+	}
+	return problem
+}
+
+// wait waits for d, or until ctx is done.
+func wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func decodeJWS(t *testing.T, key *ecdsa.PrivateKey, body []byte) (map[string]interface{}, string) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(body, &jws); err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signature) != 64 {
+		t.Fatalf("expected a 64 byte signature, got %d bytes", len(signature))
+	}
+	digest := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Fatal("the signature does not verify")
+	}
+
+	protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := make(map[string]interface{})
+	if err = json.Unmarshal(protected, &header); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return header, string(payload)
+}
+
+func TestSignJWS(t *testing.T) {
+	key := newKey(t)
+
+	body, err := signJWS(key, "", "nonce-1", "https://ca/new-account", map[string]bool{"termsOfServiceAgreed": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, payload := decodeJWS(t, key, body)
+	if header["alg"] != "ES256" || header["nonce"] != "nonce-1" || header["url"] != "https://ca/new-account" {
+		t.Fatalf("unexpected protected header %v", header)
+	}
+	if _, found := header["kid"]; found {
+		t.Fatalf("expected no kid without an account, got %v", header)
+	}
+	jwk, ok := header["jwk"].(map[string]interface{})
+	if !ok || jwk["crv"] != "P-256" || jwk["kty"] != "EC" {
+		t.Fatalf("expected the embedded P-256 key, got %v", header["jwk"])
+	}
+	if payload != `{"termsOfServiceAgreed":true}` {
+		t.Fatalf("unexpected payload %s", payload)
+	}
+
+	body, err = signJWS(key, "https://ca/account/1", "nonce-2", "https://ca/order/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, payload = decodeJWS(t, key, body)
+	if header["kid"] != "https://ca/account/1" {
+		t.Fatalf("expected the account URL as kid, got %v", header)
+	}
+	if _, found := header["jwk"]; found {
+		t.Fatalf("expected no embedded key with a kid, got %v", header)
+	}
+	if payload != "" {
+		t.Fatalf("expected an empty POST-as-GET payload, got %s", payload)
+	}
+}
+
+func TestChallengeResponses(t *testing.T) {
+	key := newKey(t)
+	client := &Client{Key: key}
+
+	// RFC 7638 thumbprints hash the required members in lexicographic order without whitespace.
+	jwk := newJWK(&key.PublicKey)
+	digest := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, jwk.X, jwk.Y)))
+	keyAuth := "token." + base64.RawURLEncoding.EncodeToString(digest[:])
+
+	response, err := client.HTTP01ChallengeResponse("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != keyAuth {
+		t.Fatalf("expected http-01 response %s, got %s", keyAuth, response)
+	}
+
+	if path := client.HTTP01ChallengePath("token"); path != "/.well-known/acme-challenge/token" {
+		t.Fatalf("unexpected http-01 path %s", path)
+	}
+
+	record, err := client.DNS01ChallengeRecord("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest = sha256.Sum256([]byte(keyAuth))
+	if expected := base64.RawURLEncoding.EncodeToString(digest[:]); record != expected {
+		t.Fatalf("expected dns-01 record %s, got %s", expected, record)
+	}
+}
+
+func TestBadNonceRetried(t *testing.T) {
+	nonces := 0
+	orders := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces++
+		w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", nonces))
+		switch r.URL.Path {
+		case "/directory":
+			fmt.Fprintf(w, `{"newNonce": "%[1]s/nonce", "newAccount": "%[1]s/new-account", "newOrder": "%[1]s/new-order"}`,
+				server.URL)
+		case "/nonce":
+		case "/new-order":
+			orders++
+			if orders == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"type": "urn:ietf:params:acme:error:badNonce", "detail": "stale nonce"}`)
+				return
+			}
+			w.Header().Set("Location", server.URL+"/order/1")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"status": "pending", "authorizations": ["%s/authz/1"], "finalize": "%s/finalize/1"}`,
+				server.URL, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{Key: newKey(t), AccountURL: server.URL + "/account/1", DirectoryURL: server.URL + "/directory"}
+	order, err := client.AuthorizeOrder(context.Background(), []string{"example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders != 2 {
+		t.Fatalf("expected the order to be retried once, got %d attempts", orders)
+	}
+	if order.URI != server.URL+"/order/1" || order.Status != StatusPending || len(order.AuthzURLs) != 1 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+}
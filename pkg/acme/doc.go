@@ -0,0 +1,5 @@
+// Package acme is a minimal client for ACME certificate authorities implementing RFC 8555,
+// such as Let's Encrypt. It supports what certs:issue needs: registering an account,
+// ordering a certificate, answering http-01 and dns-01 challenges and finalizing the order,
+// with ECDSA P-256 account keys.
+package acme
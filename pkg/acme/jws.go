@@ -0,0 +1,93 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// jwk is the JSON Web Key of an ECDSA P-256 public key. Its fields are in the order RFC 7638
+// requires for computing thumbprints.
+type jwk struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newJWK(key *ecdsa.PublicKey) jwk {
+	return jwk{
+		Crv: "P-256",
+		Kty: "EC",
+		X:   base64.RawURLEncoding.EncodeToString(padBytes(key.X, 32)),
+		Y:   base64.RawURLEncoding.EncodeToString(padBytes(key.Y, 32)),
+	}
+}
+
+// thumbprint returns the RFC 7638 thumbprint of a public key.
+func thumbprint(key *ecdsa.PublicKey) string {
+	contents, _ := json.Marshal(newJWK(key))
+	digest := sha256.Sum256(contents)
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// signJWS signs payload as a flattened JWS for url. The key is identified by kid, the
+// account URL, or embedded as a JWK if kid is empty. A nil payload is signed as the empty
+// payload of a POST-as-GET request.
+func signJWS(key *ecdsa.PrivateKey, kid, nonce, url string, payload interface{}) ([]byte, error) {
+	header := struct {
+		Alg   string `json:"alg"`
+		JWK   *jwk   `json:"jwk,omitempty"`
+		KID   string `json:"kid,omitempty"`
+		Nonce string `json:"nonce"`
+		URL   string `json:"url"`
+	}{Alg: "ES256", KID: kid, Nonce: nonce, URL: url}
+	if kid == "" {
+		k := newJWK(&key.PublicKey)
+		header.JWK = &k
+	}
+
+	protected, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var encodedPayload string
+	if payload != nil {
+		contents, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		encodedPayload = base64.RawURLEncoding.EncodeToString(contents)
+	}
+
+	encodedProtected := base64.RawURLEncoding.EncodeToString(protected)
+	digest := sha256.Sum256([]byte(encodedProtected + "." + encodedPayload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}{
+		Protected: encodedProtected,
+		Payload:   encodedPayload,
+		Signature: base64.RawURLEncoding.EncodeToString(append(padBytes(r, 32), padBytes(s, 32)...)),
+	})
+}
+
+// padBytes returns the big-endian bytes of n, left padded with zeros to size bytes.
+func padBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
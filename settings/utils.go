@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 var filepathRegex = regexp.MustCompile(`^.*[/\\].+\.json$`)
@@ -24,3 +25,9 @@ func locateSettingsFile(cf string) string {
 
 	return filepath.Join(FindHome(), ".deis", cf+".json")
 }
+
+// StatePath returns the path of a file for storing client-side state, such as saved process
+// counts, next to the settings file. Each settings file has its own state files.
+func StatePath(cf, name string) string {
+	return strings.TrimSuffix(locateSettingsFile(cf), ".json") + "-" + name + ".json"
+}
@@ -31,3 +31,10 @@ func TestSelectSettings(t *testing.T) {
 	os.Setenv("DEIS_PROFILE", location)
 	assert.Equal(t, locateSettingsFile(""), location, "case")
 }
+
+func TestStatePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, StatePath("test", "scale"), filepath.Join(FindHome(), ".deis", "test-scale.json"), "path")
+	assert.Equal(t, StatePath("/opt/test.json", "scale"), "/opt/test-scale.json", "path")
+}